@@ -0,0 +1,63 @@
+package embat
+
+import (
+	"time"
+)
+
+// AdaptiveBatchSize configures additive increase, multiplicative decrease (AIMD) batch sizing.
+// After each full batch processed within TargetLatency and MaxErrorRate the batch size grows by Increase,
+// after each batch that is slower or fails more often it is multiplied by Decrease.
+type AdaptiveBatchSize struct {
+	// MinBatchSize is the smallest batch size, values below 1 default to 1.
+	MinBatchSize int
+	// MaxBatchSize is the largest batch size, it is lowered to the capacity of a bounded queue if that is smaller
+	// and values below 1 default to that capacity, or no limit if the queue is unbounded.
+	MaxBatchSize int
+	// TargetLatency is how long processing a batch should take at most.
+	TargetLatency time.Duration
+	// MaxErrorRate is the fraction of failed jobs in a batch above which the batch size shrinks.
+	MaxErrorRate float64
+	// Increase is added to the batch size after a full batch within target, values below 1 default to 1.
+	Increase int
+	// Decrease is the factor the batch size is multiplied by after a batch over target,
+	// values outside (0, 1) default to 0.5.
+	Decrease float64
+}
+
+// next returns the batch size following a batch of n jobs dispatched with the given size,
+// that took elapsed to process and in which failed jobs failed.
+func (a *AdaptiveBatchSize) next(current, size, n int, elapsed time.Duration, failed int) int {
+	if elapsed > a.TargetLatency || (n > 0 && float64(failed)/float64(n) > a.MaxErrorRate) {
+		decrease := a.Decrease
+		if decrease <= 0 || decrease >= 1 {
+			decrease = 0.5
+		}
+		return a.clamp(int(float64(current) * decrease))
+	}
+	// A partial batch says nothing about whether a larger one would be processed in time.
+	if n < size {
+		return current
+	}
+	increase := a.Increase
+	if increase < 1 {
+		increase = 1
+	}
+	return a.clamp(current + increase)
+}
+
+// bound lowers the maximum batch size to capacity, the number of jobs a bounded queue holds,
+// so a full batch always fits in the queue. A capacity of zero means the queue is unbounded.
+func (a *AdaptiveBatchSize) bound(capacity int) {
+	if capacity > 0 && (a.MaxBatchSize <= 0 || a.MaxBatchSize > capacity) {
+		a.MaxBatchSize = capacity
+	}
+}
+
+// clamp bounds the batch size by the minimum and maximum.
+func (a *AdaptiveBatchSize) clamp(size int) int {
+	minSize := max(a.MinBatchSize, 1)
+	if a.MaxBatchSize > 0 && size > a.MaxBatchSize {
+		size = a.MaxBatchSize
+	}
+	return max(size, minSize)
+}
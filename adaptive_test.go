@@ -0,0 +1,71 @@
+package embat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdaptiveBatchSize_next tests how the batch size grows and shrinks.
+func TestAdaptiveBatchSize_next(t *testing.T) {
+	a := &AdaptiveBatchSize{
+		MinBatchSize:  2,
+		MaxBatchSize:  10,
+		TargetLatency: 100 * time.Millisecond,
+		MaxErrorRate:  0.25,
+		Increase:      2,
+		Decrease:      0.5,
+	}
+	tests := []struct {
+		name    string
+		current int
+		n       int
+		elapsed time.Duration
+		failed  int
+		want    int
+	}{
+		{name: "full batch within target grows", current: 4, n: 4, elapsed: 10 * time.Millisecond, want: 6},
+		{name: "partial batch within target keeps size", current: 4, n: 3, elapsed: 10 * time.Millisecond, want: 4},
+		{name: "growth is capped at maximum", current: 9, n: 9, elapsed: 10 * time.Millisecond, want: 10},
+		{name: "slow batch shrinks", current: 8, n: 8, elapsed: 200 * time.Millisecond, want: 4},
+		{name: "failing batch shrinks", current: 8, n: 8, elapsed: 10 * time.Millisecond, failed: 3, want: 4},
+		{name: "errors within rate grow", current: 8, n: 8, elapsed: 10 * time.Millisecond, failed: 2, want: 10},
+		{name: "shrinking is capped at minimum", current: 3, n: 3, elapsed: 200 * time.Millisecond, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, a.next(tt.current, tt.current, tt.n, tt.elapsed, tt.failed))
+		})
+	}
+}
+
+// TestAdaptiveBatchSize_bound tests that the maximum batch size is lowered to the capacity of a bounded queue.
+func TestAdaptiveBatchSize_bound(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      int
+		capacity int
+		want     int
+	}{
+		{name: "no maximum", max: 0, capacity: 10, want: 10},
+		{name: "maximum above capacity", max: 20, capacity: 10, want: 10},
+		{name: "maximum below capacity", max: 5, capacity: 10, want: 5},
+		{name: "unbounded queue", max: 0, capacity: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AdaptiveBatchSize{MaxBatchSize: tt.max}
+			a.bound(tt.capacity)
+			assert.Equal(t, tt.want, a.MaxBatchSize)
+		})
+	}
+}
+
+// TestAdaptiveBatchSize_defaults tests the defaults of the increase and decrease.
+func TestAdaptiveBatchSize_defaults(t *testing.T) {
+	a := &AdaptiveBatchSize{MaxBatchSize: 100, TargetLatency: time.Second}
+	assert.Equal(t, 11, a.next(10, 10, 10, 0, 0))
+	assert.Equal(t, 5, a.next(10, 10, 10, 2*time.Second, 0))
+	assert.Equal(t, 1, a.clamp(0))
+}
@@ -0,0 +1,131 @@
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+package embat
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetter receives jobs that failed permanently, this interface can be implemented by the consumer.
+// Send is called from the goroutine processing the job's batch, so implementations must be safe for concurrent use.
+type DeadLetter[J any] interface {
+	// Send receives a job that failed permanently.
+	Send(entry DeadLetterEntry[J]) error
+}
+
+// DeadLetterEntry describes a job that failed permanently.
+type DeadLetterEntry[J any] struct {
+	// Job is the job as it was last dispatched to the processor.
+	Job Job[J]
+	// Err is the final error of the job.
+	Err error
+	// Attempts records every failed attempt at processing the job, the last one holds Err.
+	Attempts []Attempt
+}
+
+// Attempt records a failed attempt at processing a job.
+type Attempt struct {
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+	// Err is the error the attempt failed with.
+	Err error
+	// Time is when the attempt failed.
+	Time time.Time
+}
+
+// NewMemoryDeadLetter creates a DeadLetter that keeps entries in memory.
+func NewMemoryDeadLetter[J any]() *MemoryDeadLetter[J] {
+	return &MemoryDeadLetter[J]{}
+}
+
+// MemoryDeadLetter is a DeadLetter that keeps entries in memory.
+type MemoryDeadLetter[J any] struct {
+	mu      sync.Mutex
+	entries []DeadLetterEntry[J]
+}
+
+// Send appends the entry.
+func (m *MemoryDeadLetter[J]) Send(entry DeadLetterEntry[J]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// Entries returns a copy of the entries received so far.
+func (m *MemoryDeadLetter[J]) Entries() []DeadLetterEntry[J] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]DeadLetterEntry[J], len(m.entries))
+	copy(entries, m.entries)
+	return entries
+}
+
+// NewFileDeadLetter creates a DeadLetter that appends entries as JSON lines to the file at path,
+// creating it if necessary. The job data is encoded with encoding/json and errors as their message.
+func NewFileDeadLetter[J any](path string) (*FileDeadLetter[J], error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDeadLetter[J]{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// FileDeadLetter is a DeadLetter that appends entries to a JSON lines file.
+type FileDeadLetter[J any] struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// fileEntry is the JSON encoding of a DeadLetterEntry.
+type fileEntry[J any] struct {
+	Job      fileJob[J]    `json:"job"`
+	Err      string        `json:"error"`
+	Attempts []fileAttempt `json:"attempts"`
+}
+
+// fileJob is the JSON encoding of a Job.
+type fileJob[J any] struct {
+	ID       JobID `json:"id"`
+	Data     J     `json:"data"`
+	Attempt  int   `json:"attempt"`
+	Priority int   `json:"priority"`
+}
+
+// fileAttempt is the JSON encoding of an Attempt.
+type fileAttempt struct {
+	Attempt int       `json:"attempt"`
+	Err     string    `json:"error"`
+	Time    time.Time `json:"time"`
+}
+
+// Send appends the entry to the file as a single JSON line.
+func (f *FileDeadLetter[J]) Send(entry DeadLetterEntry[J]) error {
+	e := fileEntry[J]{
+		Job:      fileJob[J]{ID: entry.Job.ID, Data: entry.Job.Data, Attempt: entry.Job.Attempt, Priority: entry.Job.Priority},
+		Attempts: make([]fileAttempt, len(entry.Attempts)),
+	}
+	if entry.Err != nil {
+		e.Err = entry.Err.Error()
+	}
+	for i, a := range entry.Attempts {
+		e.Attempts[i] = fileAttempt{Attempt: a.Attempt, Time: a.Time}
+		if a.Err != nil {
+			e.Attempts[i].Err = a.Err.Error()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.enc.Encode(e)
+}
+
+// Close closes the file.
+func (f *FileDeadLetter[J]) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Close()
+}
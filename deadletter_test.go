@@ -0,0 +1,84 @@
+package embat_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nayanbhana/embat"
+)
+
+// TestMemoryDeadLetter tests that entries are kept in memory.
+func TestMemoryDeadLetter(t *testing.T) {
+	dl := embat.NewMemoryDeadLetter[string]()
+	entry := embat.DeadLetterEntry[string]{
+		Job: embat.NewJob("test-job"),
+		Err: errors.New("failed"),
+	}
+	assert.NoError(t, dl.Send(entry))
+	assert.Equal(t, []embat.DeadLetterEntry[string]{entry}, dl.Entries())
+}
+
+// TestFileDeadLetter tests that entries are appended to a JSON lines file.
+func TestFileDeadLetter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	dl, err := embat.NewFileDeadLetter[string](path)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, data := range []string{"test-job-1", "test-job-2"} {
+		assert.NoError(t, dl.Send(embat.DeadLetterEntry[string]{
+			Job: embat.Job[string]{ID: embat.JobID(data), Data: data, Attempt: 2},
+			Err: errors.New("failed"),
+			Attempts: []embat.Attempt{
+				{Attempt: 1, Err: errors.New("transient"), Time: now},
+				{Attempt: 2, Err: errors.New("failed"), Time: now},
+			},
+		}))
+	}
+	require.NoError(t, dl.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	type line struct {
+		Job struct {
+			ID      string `json:"id"`
+			Data    string `json:"data"`
+			Attempt int    `json:"attempt"`
+		} `json:"job"`
+		Err      string `json:"error"`
+		Attempts []struct {
+			Attempt int       `json:"attempt"`
+			Err     string    `json:"error"`
+			Time    time.Time `json:"time"`
+		} `json:"attempts"`
+	}
+	var raw []string
+	var lines []line
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var l line
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &l))
+		raw = append(raw, scanner.Text())
+		lines = append(lines, l)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"job":{"id":"test-job-1","data":"test-job-1","attempt":2,"priority":0},"error":"failed",`+
+		`"attempts":[{"attempt":1,"error":"transient","time":"2024-01-02T03:04:05Z"},`+
+		`{"attempt":2,"error":"failed","time":"2024-01-02T03:04:05Z"}]}`, raw[0])
+	assert.Equal(t, "test-job-2", lines[1].Job.Data)
+	assert.Equal(t, 2, lines[1].Job.Attempt)
+	assert.Equal(t, "failed", lines[1].Err)
+	require.Len(t, lines[1].Attempts, 2)
+	assert.Equal(t, "transient", lines[1].Attempts[0].Err)
+	assert.True(t, now.Equal(lines[1].Attempts[0].Time))
+}
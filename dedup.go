@@ -0,0 +1,202 @@
+package embat
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// waiter is a caller waiting for the result of a deduplicated job.
+type waiter[R any] struct {
+	// jobID is the ID of the job the caller submitted, its result carries this ID.
+	jobID JobID
+	ch    chan Result[R]
+	// joined is when the caller joined.
+	joined time.Time
+	// span traces the caller's job, unless the caller's job is the one queued.
+	span Span
+	// stop detaches the caller from its submission context, if any.
+	stop func() bool
+	// delivered is set once the caller has received a result.
+	delivered bool
+}
+
+// coalesced is a queued job whose result is shared by every caller that submitted a job with its key.
+type coalesced[R any] struct {
+	jobID   JobID
+	waiters []*waiter[R]
+}
+
+// cached is the result of a completed job that may be reused until it expires.
+type cached[R any] struct {
+	result  Result[R]
+	expires time.Time
+}
+
+// dedup coalesces pending jobs with the same key and reuses their successful results for a window after completion.
+type dedup[J any, R any] struct {
+	key    func(Job[J]) string
+	window time.Duration
+	// observe is called with every result sent to a caller and the time since the caller joined, if set.
+	observe func(latency time.Duration, err error)
+
+	mu      sync.Mutex
+	pending map[string]*coalesced[R]
+	done    map[string]cached[R]
+	// sweep is when expired results are next removed.
+	sweep time.Time
+}
+
+// newDedup returns a dedup keyed by key that reuses results for window.
+func newDedup[J any, R any](key func(Job[J]) string, window time.Duration) *dedup[J, R] {
+	return &dedup[J, R]{
+		key:     key,
+		window:  window,
+		pending: make(map[string]*coalesced[R]),
+		done:    make(map[string]cached[R]),
+	}
+}
+
+// join registers a caller for the job with the given key and returns the channel receiving its result.
+// If a result for the key can be reused it is already on the channel and the waiter is nil.
+// leader is true if no job with the key is pending, the caller's job must then be queued and traced with span,
+// otherwise the waiter ends span once it receives its result.
+func (d *dedup[J, R]) join(key string, jobID JobID, span Span) (ch chan Result[R], w *waiter[R], leader bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w = &waiter[R]{jobID: jobID, ch: make(chan Result[R], 1), joined: time.Now(), span: span}
+	if c, ok := d.done[key]; ok {
+		if time.Now().Before(c.expires) {
+			d.send(w, c.result, true)
+			return w.ch, nil, false
+		}
+		delete(d.done, key)
+	}
+	c, ok := d.pending[key]
+	if !ok {
+		c = &coalesced[R]{jobID: jobID}
+		d.pending[key] = c
+		w.span = nil
+		leader = true
+	} else if span != nil {
+		span.AddEvent(EventCoalesced, KeyJobID, c.jobID)
+	}
+	c.waiters = append(c.waiters, w)
+	return w.ch, w, leader
+}
+
+// watch attaches the stop func of a caller's context watcher, calling it straight away if the caller is done.
+func (d *dedup[J, R]) watch(w *waiter[R], stop func() bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w.delivered {
+		stop()
+		return
+	}
+	w.stop = stop
+}
+
+// leave delivers err to a caller that stopped waiting. If no callers are left for the job, it returns the job's ID
+// and true, the job should then be abandoned.
+func (d *dedup[J, R]) leave(key string, w *waiter[R], err error) (JobID, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w.delivered {
+		return "", false
+	}
+	d.send(w, Result[R]{Err: err}, true)
+	c := d.pending[key]
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			break
+		}
+	}
+	if len(c.waiters) > 0 {
+		return "", false
+	}
+	delete(d.pending, key)
+	return c.jobID, true
+}
+
+// complete fans the result of a job out to every caller waiting for it and caches it if it succeeded.
+// If the job was rejected, the caller that submitted it is not observed as it was counted as rejected.
+func (d *dedup[J, R]) complete(key string, result Result[R], rejected bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, ok := d.pending[key]
+	// The job may have been abandoned by its callers and replaced by a new one.
+	if !ok || c.jobID != result.JobID {
+		return
+	}
+	delete(d.pending, key)
+	for _, w := range c.waiters {
+		d.send(w, result, !rejected || w.jobID != c.jobID)
+	}
+	if d.window <= 0 || result.Err != nil {
+		return
+	}
+	now := time.Now()
+	d.done[key] = cached[R]{result: result, expires: now.Add(d.window)}
+	if now.After(d.sweep) {
+		for k, c := range d.done {
+			if !now.Before(c.expires) {
+				delete(d.done, k)
+			}
+		}
+		d.sweep = now.Add(d.window)
+	}
+}
+
+// send delivers the result to the caller under its own job ID and observes it if observe is set,
+// the caller must hold the lock.
+func (d *dedup[J, R]) send(w *waiter[R], result Result[R], observe bool) {
+	if w.stop != nil {
+		w.stop()
+	}
+	result.JobID = w.jobID
+	if w.span != nil {
+		w.span.AddEvent(EventCompleted)
+		w.span.End(result.Err)
+	}
+	w.ch <- result
+	close(w.ch)
+	w.delivered = true
+	if observe && d.observe != nil {
+		d.observe(time.Since(w.joined), result.Err)
+	}
+}
+
+// coalesce submits a job with deduplication: the caller waits for the pending job with the same key if there is one,
+// otherwise its job is queued and its result shared with every caller that joins it.
+func (mb *MicroBatcher[J, R]) coalesce(ctx context.Context, job Job[J], weight int, block bool, span Span) (<-chan Result[R], error) {
+	key := mb.dedup.key(job)
+	resultCh, w, leader := mb.dedup.join(key, job.ID, span)
+	if w == nil {
+		mb.log.Debug("job result reused", KeyJobID, job.ID)
+		mb.metrics.JobSubmitted()
+		return resultCh, nil
+	}
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			if jobID, ok := mb.dedup.leave(key, w, ctx.Err()); ok && mb.results.abandon(jobID, ctx.Err()) {
+				mb.log.Debug("job withdrawn", KeyJobID, jobID, KeyError, ctx.Err())
+			}
+		})
+		mb.dedup.watch(w, stop)
+	}
+	if !leader {
+		mb.log.Debug("job coalesced", KeyJobID, job.ID)
+		mb.metrics.JobSubmitted()
+		return resultCh, nil
+	}
+	mb.results.addFunc(job.ID, func(result Result[R], rejected bool) {
+		mb.dedup.complete(key, result, rejected)
+	})
+	held := holdSpan(span)
+	mb.results.trace(job.ID, held)
+	if err := mb.push(ctx, job, weight, block, held); err != nil {
+		return resultCh, err
+	}
+	return resultCh, nil
+}
@@ -0,0 +1,65 @@
+package embat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_dedup tests that callers of the same key share one job and its result is reused within the window.
+func Test_dedup(t *testing.T) {
+	d := newDedup[string, int](func(job Job[string]) string { return job.Data }, time.Minute)
+	ch1, w1, leader := d.join("k", "job-1", nil)
+	assert.NotNil(t, w1)
+	assert.True(t, leader)
+	ch2, w2, leader := d.join("k", "job-2", nil)
+	assert.NotNil(t, w2)
+	assert.False(t, leader)
+
+	d.complete("k", Result[int]{JobID: "job-1", Result: 42}, false)
+	assert.Equal(t, Result[int]{JobID: "job-1", Result: 42}, <-ch1)
+	assert.Equal(t, Result[int]{JobID: "job-2", Result: 42}, <-ch2)
+
+	ch3, w3, leader := d.join("k", "job-3", nil)
+	assert.Nil(t, w3)
+	assert.False(t, leader)
+	assert.Equal(t, Result[int]{JobID: "job-3", Result: 42}, <-ch3)
+}
+
+// Test_dedup_errors_not_reused tests that failed results are shared with waiting callers but not reused.
+func Test_dedup_errors_not_reused(t *testing.T) {
+	d := newDedup[string, int](func(job Job[string]) string { return job.Data }, time.Minute)
+	errFailed := errors.New("failed")
+	ch, _, _ := d.join("k", "job-1", nil)
+	d.complete("k", Result[int]{JobID: "job-1", Err: errFailed}, false)
+	assert.ErrorIs(t, (<-ch).Err, errFailed)
+
+	_, w, leader := d.join("k", "job-2", nil)
+	assert.NotNil(t, w)
+	assert.True(t, leader)
+}
+
+// Test_dedup_leave tests that the job is abandoned only once every caller has stopped waiting.
+func Test_dedup_leave(t *testing.T) {
+	d := newDedup[string, int](func(job Job[string]) string { return job.Data }, 0)
+	ch1, w1, _ := d.join("k", "job-1", nil)
+	ch2, w2, _ := d.join("k", "job-2", nil)
+
+	_, abandon := d.leave("k", w1, context.Canceled)
+	assert.False(t, abandon)
+	assert.ErrorIs(t, (<-ch1).Err, context.Canceled)
+
+	jobID, abandon := d.leave("k", w2, context.Canceled)
+	assert.True(t, abandon)
+	assert.Equal(t, JobID("job-1"), jobID)
+	assert.ErrorIs(t, (<-ch2).Err, context.Canceled)
+
+	// A late result of the abandoned job is ignored.
+	d.complete("k", Result[int]{JobID: "job-1", Result: 42}, false)
+	assert.Empty(t, d.done)
+	_, abandon = d.leave("k", w2, context.Canceled)
+	assert.False(t, abandon)
+}
@@ -2,7 +2,11 @@
 package embat
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,27 +20,77 @@ type BatchProcessor[J any, R any] interface {
 	Process(batch []Job[J]) []Result[R]
 }
 
+// BatchProcessorContext processes a batch of jobs with a context, this interface should be implemented by the consumer.
+type BatchProcessorContext[J any, R any] interface {
+	// ProcessContext processes a batch of jobs and returns their results.
+	// ctx is cancelled when the batcher stops or when every job in the batch has been abandoned by its caller.
+	ProcessContext(ctx context.Context, batch []Job[J]) []Result[R]
+}
+
+// FallibleBatchProcessor processes a batch of jobs with a context and may fail the batch as a whole,
+// this interface should be implemented by the consumer.
+type FallibleBatchProcessor[J any, R any] interface {
+	// ProcessBatch processes a batch of jobs and returns their results, or an error that fails every job in the batch.
+	// ctx is cancelled when the batcher stops or when every job in the batch has been abandoned by its caller.
+	ProcessBatch(ctx context.Context, batch []Job[J]) ([]Result[R], error)
+}
+
 // NewMicroBatcher creates a new MicroBatcher with given options.
 func NewMicroBatcher[J any, R any](processor BatchProcessor[J, R], opts ...Option[J, R]) *MicroBatcher[J, R] {
+	return NewMicroBatcherContext[J, R](contextProcessor[J, R]{processor}, opts...)
+}
+
+// NewMicroBatcherContext creates a new MicroBatcher with a context-aware processor and given options.
+func NewMicroBatcherContext[J any, R any](processor BatchProcessorContext[J, R], opts ...Option[J, R]) *MicroBatcher[J, R] {
+	return NewFallibleMicroBatcher[J, R](infallibleProcessor[J, R]{processor}, opts...)
+}
+
+// NewFallibleMicroBatcher creates a new MicroBatcher with a processor that may fail whole batches and given options.
+func NewFallibleMicroBatcher[J any, R any](processor FallibleBatchProcessor[J, R], opts ...Option[J, R]) *MicroBatcher[J, R] {
+	ctx, cancel := context.WithCancel(context.Background())
 	mb := &MicroBatcher[J, R]{
-		processor: processor,
-		batchSize: 100,
-		frequency: 5 * time.Second,
-		jobs: jobsC[J]{
-			c: make(chan Job[J], 1),
-		},
-		logger: noOpLogger{},
+		processor:         processor,
+		maxBatchesPerTick: 1,
+		concurrency:       1,
+		logger:            noOpLogger{},
+		metrics:           noOpMetrics{},
+		tracer:            noOpTracer{},
 		results: results[R]{
-			m: make(map[JobID]chan Result[R]),
+			m: make(map[JobID]*pending[R]),
 		},
-		shutdownCh: make(chan struct{}),
+		shutdownCh:  make(chan struct{}),
+		sealed:      make(chan struct{}),
+		flush:       make(chan struct{}, 1),
+		reconfigure: make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 
+	mb.batchSize.Store(100)
+	mb.frequency.Store(int64(5 * time.Second))
+
 	for _, opt := range opts {
 		opt(mb)
 	}
+	if mb.adaptive != nil {
+		mb.batchSize.Store(int64(mb.adaptive.clamp(mb.BatchSize())))
+	}
+	mb.sem = make(chan struct{}, mb.concurrency)
+	mb.jobs = mb.newQueue()
+	if mb.adaptive != nil {
+		mb.adaptive.bound(mb.capacity)
+		mb.batchSize.Store(int64(mb.adaptive.clamp(mb.BatchSize())))
+	}
+	mb.log = mb.newLog()
+	mb.results.observe = mb.completed
+	if mb.dedup != nil {
+		mb.dedup.observe = mb.completed
+	}
+	if _, ok := mb.logger.(noOpLogger); ok && mb.structured != nil {
+		mb.logger = printfLogger{logger: mb.structured}
+	}
 
-	mb.wg.Add(1)
 	go mb.start()
 	return mb
 }
@@ -56,6 +110,11 @@ type Job[J any] struct {
 	ID JobID
 	// Data holds the specific data for the job, of the generic type J.
 	Data J
+	// Attempt is the number of times the job has been dispatched to the processor, including the current one.
+	// It is set by the MicroBatcher.
+	Attempt int
+	// Priority orders jobs in a priority queue, jobs with a higher priority are processed first.
+	Priority int
 }
 
 // NewResult creates a new Result with the given JobID and outcome.
@@ -78,55 +137,306 @@ type Result[R any] struct {
 	Err error
 }
 
-// jobs is an interface for handling jobs
-// allows for different implementations of jobs
-// e.g. slice, channel
-type jobs[J any] interface {
-	add(job Job[J])
-	next(defaultBatchSize int) []Job[J]
-	length() int
-	close()
-}
-
 // MicroBatcher handles batching and processing of jobs.
 type MicroBatcher[J any, R any] struct {
 	// batchSize is the maximum number of jobs in each batch.
-	batchSize int
+	batchSize atomic.Int64
+	// adaptive adjusts batchSize to the observed processing latency and error rate, if nil the batch size is fixed.
+	adaptive *AdaptiveBatchSize
 	// frequency is the duration between batch processing attempts.
-	frequency time.Duration
+	frequency atomic.Int64
+	// maxBatchesPerTick is the maximum number of batches dispatched on each tick,
+	// if it is not positive every tick drains the entire backlog.
+	maxBatchesPerTick int
+	// overflowPolicy decides what happens to a submitted job when the queue is full.
+	overflowPolicy OverflowPolicy
+	// overflowTimeout is how long a submission waits for room in the queue with OverflowBlockTimeout.
+	overflowTimeout time.Duration
+	// concurrency is the maximum number of batches processed at once.
+	concurrency int
+	// ordered delivers results in the order their batches were dispatched.
+	ordered bool
+	// sem limits the number of batches in flight to concurrency.
+	sem chan struct{}
+	// workers is a wait group for the batches in flight.
+	workers sync.WaitGroup
+	// lastBatch is closed once the results of the most recently dispatched batch have been delivered,
+	// it is only used when ordered is set.
+	lastBatch chan struct{}
 	// jobs is the current list of pending jobs to be processed.
-	jobs jobs[J]
+	jobs Queue[J]
+	// weigher returns the weight of a job, if nil batches are only limited by the batch size.
+	weigher func(Job[J]) int
+	// maxWeight is the maximum cumulative weight of the jobs in a batch.
+	maxWeight int
+	// oversizePolicy decides what happens to a submitted job heavier than maxWeight.
+	oversizePolicy OversizePolicy
+	// queuedWeight is the cumulative weight of the queued jobs.
+	queuedWeight atomic.Int64
+	// carry holds, by partition key, a job taken off the queue that did not fit in the previous batch,
+	// it leads the next batch of its partition.
+	carry map[string]Job[J]
+	// partitionKey returns the partition of a job, if nil all jobs share a single queue.
+	partitionKey func(Job[J]) string
+	// partitions is the queue holding a queue per partition key, if jobs are partitioned.
+	partitions *partitionQueue[J]
+	// priority is set if jobs are queued by priority.
+	priority bool
+	// maxWait is how long a queued job may wait before it is taken ahead of higher priorities.
+	maxWait time.Duration
+	// dedup coalesces jobs with the same key, if nil every job is processed.
+	dedup *dedup[J, R]
+	// queue is the queue supplied by the consumer, if nil a queue is built from queueCapacity.
+	queue Queue[J]
+	// queueCapacity is the maximum number of queued jobs, zero means the batch size and
+	// a negative value means the queue is unbounded.
+	queueCapacity int
+	// capacity is the capacity of the queue built from queueCapacity, zero if it is unbounded or was supplied.
+	capacity int
 	// logger is the logger for the MicroBatcher.
 	// default is no logging, if you want logging you can provide your own logger.
 	logger Logger
-	// processor is the BatchProcessor supplied by the consumer that processes batches of jobs.
-	processor BatchProcessor[J, R]
+	// metrics receives measurements of the MicroBatcher, by default they are discarded.
+	metrics Metrics
+	// tracer traces jobs and batches, by default nothing is traced.
+	tracer Tracer
+	// structured is the structured logger supplied by the consumer, if any.
+	structured StructuredLogger
+	// log is the structured logger the MicroBatcher writes to, it wraps logger unless a structured logger is set.
+	log StructuredLogger
+	// processTimeout is how long a batch may be processed before its jobs fail with ErrProcessTimeout,
+	// zero means no timeout.
+	processTimeout time.Duration
+	// retryPolicy decides whether failed jobs are retried, if nil they are not.
+	retryPolicy *RetryPolicy
+	// retrying is the number of jobs waiting for their retry backoff to pass.
+	retrying atomic.Int64
+	// deadLetter receives jobs that failed permanently, if nil they are only reported to the caller.
+	deadLetter DeadLetter[J]
+	// unmatchedHook is called for every result that does not match a job of its batch.
+	unmatchedHook func(result Result[R], err error)
+	// processor is the processor supplied by the consumer that processes batches of jobs.
+	processor FallibleBatchProcessor[J, R]
 	// results maps each job ID to its result channel.
 	results results[R]
+	// counters accumulates the totals reported by Stats.
+	counters counters
 	// shutdownOnce ensures that shutdown is called only once.
 	shutdownOnce sync.Once
 	// shutdownCalled flag to indicate if shutdown has been called.
 	shutdownCalled atomic.Bool
 	// shutdownCh is a channel to signal shutdown.
 	shutdownCh chan struct{}
-	// wg is a wait group to ensure all jobs are processed before shutdown.
-	wg sync.WaitGroup
+	// abortOnce ensures that pending jobs are failed only once when a shutdown gives up.
+	abortOnce sync.Once
+	// submitMu guards submissions against shutdown, so no job is queued after the queue is closed.
+	submitMu sync.RWMutex
+	// sealed is closed once every submission that raced with shutdown has finished queueing its job.
+	sealed chan struct{}
+	// flush signals the start loop that a full batch is queued or that a job was queued during shutdown.
+	flush chan struct{}
+	// reconfigure signals the start loop that the frequency has changed.
+	reconfigure chan struct{}
+	// done is closed once all jobs have been processed after shutdown.
+	done chan struct{}
+	// ctx is the parent of every batch context, it is cancelled when the batcher stops.
+	ctx context.Context
+	// cancel cancels ctx.
+	cancel context.CancelFunc
 }
 
 // Submit adds a job to the MicroBatcher and returns a channel to receive the result
 func (mb *MicroBatcher[J, R]) Submit(job Job[J]) <-chan Result[R] {
-	if mb.isShutdown() {
-		mb.logger.Debug("shutdown has been initiated, submit failed for job with id: %s", job.ID)
-		return mb.shutdownResult(job)
+	return mb.SubmitContext(context.Background(), job)
+}
+
+// SubmitContext adds a job to the MicroBatcher and returns a channel to receive the result.
+// If ctx is done before the job has been processed, the job is withdrawn and ctx.Err() is sent on the channel instead.
+func (mb *MicroBatcher[J, R]) SubmitContext(ctx context.Context, job Job[J]) <-chan Result[R] {
+	resultCh, _ := mb.submit(ctx, job, true)
+	return resultCh
+}
+
+// SubmitWithPriority adds a job with the given priority to the MicroBatcher and returns a channel to receive the result.
+// The priority only takes effect with a priority queue, see WithPriority.
+func (mb *MicroBatcher[J, R]) SubmitWithPriority(job Job[J], priority int) <-chan Result[R] {
+	job.Priority = priority
+	return mb.Submit(job)
+}
+
+// TrySubmit adds a job to the MicroBatcher without blocking and reports synchronously whether it was accepted.
+// It returns ErrShutdown once shutdown has been initiated and ErrQueueFull if the queue has no room,
+// unless the overflow policy is OverflowDropOldest. The result of an accepted job is sent on the returned channel.
+func (mb *MicroBatcher[J, R]) TrySubmit(job Job[J]) (<-chan Result[R], error) {
+	resultCh, err := mb.submit(context.Background(), job, false)
+	if err != nil {
+		return nil, err
+	}
+	return resultCh, nil
+}
+
+// submit queues the job according to the overflow policy, never waiting for room in the queue if block is false.
+// If the job is not accepted the returned channel holds the error as the job's result.
+func (mb *MicroBatcher[J, R]) submit(ctx context.Context, job Job[J], block bool) (<-chan Result[R], error) {
+	// The lock is only taken for writing once shutdown has begun, so rather than queue behind it
+	// while earlier submissions wait for room, the job is rejected.
+	locked := mb.submitMu.TryRLock()
+	if locked {
+		defer mb.submitMu.RUnlock()
 	}
 	if job.ID == "" {
 		job.ID = NewJobID()
 	}
+	span := mb.tracer.StartJob(ctx, job.ID)
+	if !locked || mb.isShutdown() {
+		mb.log.Warn("job submitted after shutdown", KeyJobID, job.ID)
+		mb.rejected(ErrShutdown)
+		span.End(ErrShutdown)
+		return errResult[R](job.ID, ErrShutdown), ErrShutdown
+	}
+	if err := ctx.Err(); err != nil {
+		mb.log.Debug("job submitted with done context", KeyJobID, job.ID, KeyError, err)
+		mb.rejected(err)
+		span.End(err)
+		return errResult[R](job.ID, err), err
+	}
+	weight := mb.weigh(job)
+	if weight > mb.maxWeight && mb.weigher != nil && mb.oversizePolicy == OversizeReject {
+		err := fmt.Errorf("%w: weight %d exceeds %d", ErrOversize, weight, mb.maxWeight)
+		mb.log.Warn("oversize job rejected", KeyJobID, job.ID, KeyError, err)
+		mb.rejected(err)
+		span.End(err)
+		return errResult[R](job.ID, err), err
+	}
+	if mb.dedup != nil {
+		return mb.coalesce(ctx, job, weight, block, span)
+	}
 	resultCh := make(chan Result[R], 1)
-	mb.jobs.add(job)
+	// The result channel is registered before the job is queued so it cannot be processed without one.
 	mb.results.add(job.ID, resultCh)
-	mb.logger.Debug("successfully submitted job with id: %s", job.ID)
-	return resultCh
+	held := holdSpan(span)
+	mb.results.trace(job.ID, held)
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			if mb.results.abandon(job.ID, ctx.Err()) {
+				mb.log.Debug("job withdrawn", KeyJobID, job.ID, KeyError, ctx.Err())
+			}
+		})
+		mb.results.watch(job.ID, stop)
+	}
+	if err := mb.push(ctx, job, weight, block, held); err != nil {
+		return resultCh, err
+	}
+	return resultCh, nil
+}
+
+// push queues a job whose result has been registered and whose span is held, abandoning it if it could not be queued.
+func (mb *MicroBatcher[J, R]) push(ctx context.Context, job Job[J], weight int, block bool, span *heldSpan) error {
+	// The weight is counted before the job is queued so it cannot be taken off the queue uncounted.
+	mb.queuedWeight.Add(int64(weight))
+	// The event is recorded before the job can be dispatched, and withdrawn if the job is rejected.
+	span.AddEvent(EventEnqueued)
+	if err := mb.enqueue(ctx, job, block); err != nil {
+		span.release(false)
+		mb.queuedWeight.Add(-int64(weight))
+		// A job withdrawn while waiting for room has received its result and been counted already.
+		if mb.results.reject(job.ID, err) {
+			mb.log.Warn("job rejected", KeyJobID, job.ID, KeyError, err)
+			mb.rejected(err)
+		}
+		return err
+	}
+	span.release(true)
+	mb.notify()
+	mb.log.Debug("job submitted", KeyJobID, job.ID)
+	mb.metrics.JobSubmitted()
+	mb.metrics.QueueDepth(mb.jobs.Len())
+	return nil
+}
+
+// notify wakes the start loop if a full batch is queued or shutdown is draining the queue.
+func (mb *MicroBatcher[J, R]) notify() {
+	if mb.isShutdown() || mb.isFull() {
+		select {
+		case mb.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// dropOldest removes the oldest queued job, which is the next job unless the queue orders jobs otherwise.
+func (mb *MicroBatcher[J, R]) dropOldest() []Job[J] {
+	q, ok := mb.jobs.(oldestDropper[J])
+	if !ok {
+		return mb.jobs.Next(1)
+	}
+	if job, ok := q.dropOldest(); ok {
+		return []Job[J]{job}
+	}
+	return nil
+}
+
+// enqueue adds the job to the queue, applying the overflow policy if the queue is full.
+func (mb *MicroBatcher[J, R]) enqueue(ctx context.Context, job Job[J], block bool) error {
+	switch {
+	case mb.overflowPolicy == OverflowDropOldest:
+		for !mb.jobs.TryAdd(job) {
+			for _, old := range mb.dropOldest() {
+				mb.queuedWeight.Add(-int64(mb.weigh(old)))
+				if mb.results.abandon(old.ID, ErrDropped) {
+					mb.log.Warn("job dropped from full queue", KeyJobID, old.ID)
+				}
+			}
+		}
+		return nil
+	case !block || mb.overflowPolicy == OverflowFail:
+		if !mb.jobs.TryAdd(job) {
+			return ErrQueueFull
+		}
+		return nil
+	case mb.overflowPolicy == OverflowBlockTimeout:
+		addCtx, cancel := mb.queueContext(ctx)
+		defer cancel()
+		addCtx, cancelTimeout := context.WithTimeout(addCtx, mb.overflowTimeout)
+		defer cancelTimeout()
+		if err := mb.jobs.Add(addCtx, job); err != nil {
+			if err := mb.queueErr(ctx); err != nil {
+				return err
+			}
+			return ErrQueueFull
+		}
+		return nil
+	default:
+		addCtx, cancel := mb.queueContext(ctx)
+		defer cancel()
+		err := mb.jobs.Add(addCtx, job)
+		if qerr := mb.queueErr(ctx); err != nil && qerr != nil {
+			return qerr
+		}
+		return err
+	}
+}
+
+// queueContext returns a context for waiting for room in the queue, which is done when ctx is done
+// or when the batcher stops, so submissions stop waiting once a shutdown has been aborted.
+func (mb *MicroBatcher[J, R]) queueContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(mb.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// queueErr returns why a submission stopped waiting for room in the queue, if ctx is done or the batcher stopped.
+func (mb *MicroBatcher[J, R]) queueErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if mb.ctx.Err() != nil {
+		return ErrShutdownAborted
+	}
+	return nil
 }
 
 // Logger returns the logger for the MicroBatcher.
@@ -136,63 +446,436 @@ func (mb *MicroBatcher[J, R]) Logger() Logger {
 
 // Frequency returns the processing frequency of the MicroBatcher.
 func (mb *MicroBatcher[J, R]) Frequency() time.Duration {
-	return mb.frequency
+	return time.Duration(mb.frequency.Load())
+}
+
+// SetFrequency changes the processing frequency, the ticker is reset to the new frequency straight away.
+func (mb *MicroBatcher[J, R]) SetFrequency(frequency time.Duration) error {
+	if frequency <= 0 {
+		return ErrInvalidFrequency
+	}
+	mb.frequency.Store(int64(frequency))
+	select {
+	case mb.reconfigure <- struct{}{}:
+	default:
+	}
+	return nil
 }
 
 // BatchSize returns the batch size of the MicroBatcher.
 func (mb *MicroBatcher[J, R]) BatchSize() int {
-	return mb.batchSize
+	return int(mb.batchSize.Load())
+}
+
+// SetBatchSize changes the batch size, batches dispatched from then on hold at most size jobs.
+// With adaptive batch sizing the size is bounded by its minimum and maximum and adapts from there.
+// The capacity of the queue is not changed, so a size larger than a bounded queue can hold returns
+// ErrBatchSizeExceedsCapacity as such batches would only ever be dispatched by the ticker.
+func (mb *MicroBatcher[J, R]) SetBatchSize(size int) error {
+	if size < 1 {
+		return ErrInvalidBatchSize
+	}
+	if mb.adaptive != nil {
+		size = mb.adaptive.clamp(size)
+	}
+	if mb.capacity > 0 && size > mb.capacity {
+		return fmt.Errorf("%w: %d exceeds %d", ErrBatchSizeExceedsCapacity, size, mb.capacity)
+	}
+	mb.batchSize.Store(int64(size))
+	// A smaller batch size may have made a full batch of the jobs already queued.
+	mb.notify()
+	return nil
 }
 
-// Shutdown stops the batcher after processing all submitted jobs.
+// Concurrency returns the maximum number of batches the MicroBatcher processes at once.
+func (mb *MicroBatcher[J, R]) Concurrency() int {
+	return mb.concurrency
+}
+
+// Shutdown stops accepting jobs and returns once all previously submitted jobs have been processed.
+// It is safe to call Shutdown more than once and from multiple goroutines.
 func (mb *MicroBatcher[J, R]) Shutdown() {
+	_ = mb.ShutdownContext(context.Background())
+}
+
+// ShutdownContext stops accepting jobs and waits for all previously submitted jobs to be processed.
+// If ctx is done first, the contexts of in-flight batches are cancelled, every job still pending
+// receives ErrShutdownAborted and ctx.Err() is returned.
+func (mb *MicroBatcher[J, R]) ShutdownContext(ctx context.Context) error {
 	mb.shutdownOnce.Do(func() {
-		mb.logger.Debug("shutdown initiated")
-		mb.shutdownCalled.Swap(true)
+		mb.log.Info("shutdown initiated")
+		mb.shutdownCalled.Store(true)
+		close(mb.shutdownCh)
+		// Submissions already past the shutdown check may be blocked on a full queue,
+		// so wait for them in the background while the start loop keeps draining.
+		go func() {
+			mb.submitMu.Lock()
+			defer mb.submitMu.Unlock()
+			close(mb.sealed)
+		}()
 	})
-	close(mb.shutdownCh)
-	go mb.wg.Wait()
+
+	select {
+	case <-mb.done:
+		return nil
+	case <-ctx.Done():
+		mb.abortOnce.Do(func() {
+			// Fail the pending jobs before cancelling so a processor returning on cancellation cannot answer them first.
+			n := mb.results.failAll(ErrShutdownAborted)
+			mb.cancel()
+			mb.log.Warn("shutdown aborted", "failed_jobs", n, KeyError, ctx.Err())
+		})
+		return ctx.Err()
+	}
 }
 
 // start starts the MicroBatcher and processes jobs in batches.
 func (mb *MicroBatcher[J, R]) start() {
-	defer mb.wg.Done()
-	ticker := time.NewTicker(mb.frequency)
+	defer close(mb.done)
+	defer mb.cancel()
+	ticker := time.NewTicker(mb.Frequency())
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-mb.shutdownCh:
-			if mb.isComplete() {
-				mb.jobs.close()
-				mb.logger.Debug("all jobs processed, shutting down")
-				return
+			mb.drain()
+			mb.log.Info("all jobs processed, shut down")
+			return
+		case <-mb.flush:
+			// Dispatch full batches straight away, the ticker only bounds the latency of partial batches.
+			for mb.isFull() && !mb.isComplete() {
+				mb.processBatch()
 			}
+			mb.processDue()
+			ticker.Reset(mb.nextTick())
+		case <-mb.reconfigure:
+			ticker.Reset(mb.nextTick())
 		case <-ticker.C:
+			mb.processTick()
+			// A flush may have brought the tick forward for a partition, the next one comes a full period later.
+			ticker.Reset(mb.Frequency())
+		}
+	}
+}
+
+// drain processes every queued job without waiting for the ticker, including jobs from submissions
+// that raced with shutdown and jobs waiting to be retried, waits for batches in flight, then closes the queue.
+func (mb *MicroBatcher[J, R]) drain() {
+	for {
+		for !mb.isComplete() {
 			mb.processBatch()
 		}
+		select {
+		case <-mb.sealed:
+			for {
+				for !mb.isComplete() {
+					mb.processBatch()
+				}
+				mb.workers.Wait()
+				if mb.retrying.Load() == 0 && mb.isComplete() {
+					break
+				}
+				if mb.isComplete() {
+					// Wait for a retry to be queued once its backoff has passed.
+					<-mb.flush
+				}
+			}
+			mb.jobs.Close()
+			return
+		case <-mb.flush:
+		}
+	}
+}
+
+// processTick dispatches up to maxBatchesPerTick batches, stopping early once the queue is empty.
+// With partitioning every partition holding jobs dispatches up to maxBatchesPerTick batches.
+func (mb *MicroBatcher[J, R]) processTick() {
+	if mb.partitions != nil {
+		for _, key := range mb.partitionKeys() {
+			for i := 0; mb.maxBatchesPerTick <= 0 || i < mb.maxBatchesPerTick; i++ {
+				if !mb.hasJobs(key) {
+					break
+				}
+				mb.processPartition(key)
+			}
+		}
+		return
+	}
+	for i := 0; mb.maxBatchesPerTick <= 0 || i < mb.maxBatchesPerTick; i++ {
+		if mb.isComplete() {
+			return
+		}
+		mb.processBatch()
 	}
 }
 
-// processBatch processes the next batch of jobs and sends the results.
+// processBatch dispatches the next batch of jobs to a worker which processes it and sends the results.
+// It blocks while concurrency batches are already in flight.
 func (mb *MicroBatcher[J, R]) processBatch() {
-	batch := mb.jobs.next(mb.batchSize)
+	mb.processPartition(mb.nextKey())
+}
+
+// processPartition dispatches the next batch of jobs of the given partition, see processBatch.
+func (mb *MicroBatcher[J, R]) processPartition(key string) {
+	// Acquire a slot before taking jobs off the queue so they can still be withdrawn while waiting.
+	mb.sem <- struct{}{}
+	size := mb.BatchSize()
+	batch := mb.withdraw(mb.next(key, size))
+	mb.metrics.QueueDepth(mb.jobs.Len())
 	if len(batch) == 0 {
+		<-mb.sem
 		return
 	}
-	jobResults := mb.processor.Process(batch)
-	mb.results.sendResults(jobResults)
+	for i := range batch {
+		batch[i].Attempt++
+	}
+	batchID := uuid.NewString()
+	mb.log.Debug("batch dispatched", KeyBatchID, batchID, KeyBatchSize, len(batch))
+	mb.metrics.BatchDispatched(len(batch))
+	ctx, cancel := mb.batchContext()
+	ids := jobIDs(batch)
+	mb.results.track(ids, cancel)
+	var links []Span
+	for i, span := range mb.results.spans(ids) {
+		if span != nil {
+			span.AddEvent(EventDispatched, KeyBatchID, batchID, KeyAttempt, batch[i].Attempt)
+			links = append(links, unwrapSpan(span))
+		}
+	}
+	ctx, span := mb.tracer.StartBatch(ctx, batchID, links)
+
+	var prev, done chan struct{}
+	if mb.ordered {
+		prev, done = mb.lastBatch, make(chan struct{})
+		mb.lastBatch = done
+	}
+
+	mb.counters.lastBatch.Store(time.Now().UnixNano())
+	mb.counters.inFlight.Add(1)
+	mb.workers.Add(1)
+	go func() {
+		defer mb.workers.Done()
+		defer func() { <-mb.sem }()
+		defer mb.counters.inFlight.Add(-1)
+		defer cancel()
+		started := time.Now()
+		jobResults, cancelled, err := mb.process(ctx, batchID, batch)
+		elapsed := time.Since(started)
+		span.End(err)
+		mb.log.Debug("batch processed", KeyBatchID, batchID, KeyBatchSize, len(batch), KeyDuration, elapsed)
+		mb.metrics.BatchProcessed(len(batch), elapsed)
+		if mb.adaptive != nil && !cancelled {
+			mb.adapt(size, len(batch), elapsed, jobResults)
+		}
+		if done == nil {
+			mb.sendResults(batchID, batch, jobResults)
+			return
+		}
+		// Wait for earlier batches so results are delivered in submission order.
+		if prev != nil {
+			<-prev
+		}
+		mb.sendResults(batchID, batch, orderResults(ids, jobResults))
+		close(done)
+	}()
 }
 
-// shutdownResult returns a result channel with an error for a job submitted after shutdown.
-func (mb *MicroBatcher[J, R]) shutdownResult(job Job[J]) <-chan Result[R] {
-	ch := make(chan Result[R], 1)
-	ch <- Result[R]{
-		JobID: job.ID,
-		Err:   errors.New("job submitted after shutdown"),
+// batchContext returns the context for a batch, which times out after the process timeout if there is one.
+func (mb *MicroBatcher[J, R]) batchContext() (context.Context, context.CancelFunc) {
+	if mb.processTimeout > 0 {
+		return context.WithTimeoutCause(mb.ctx, mb.processTimeout, ErrProcessTimeout)
+	}
+	return context.WithCancel(mb.ctx)
+}
+
+// process calls the processor and returns the results and the error the whole batch failed with, if any.
+// With a process timeout the processor runs in its own goroutine, and if ctx is done first every job of the batch
+// fails with the cause without waiting for the processor to return. The returned bool is true if the cause is not
+// ErrProcessTimeout, i.e. the batch was abandoned by its callers or the batcher stopped, so the results say
+// nothing about the processor.
+func (mb *MicroBatcher[J, R]) process(ctx context.Context, batchID string, batch []Job[J]) ([]Result[R], bool, error) {
+	if mb.processTimeout <= 0 {
+		jobResults, err := mb.call(ctx, batchID, batch)
+		return jobResults, false, err
+	}
+	var jobResults []Result[R]
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		jobResults, err = mb.call(ctx, batchID, batch)
+	}()
+	select {
+	case <-done:
+		return jobResults, false, err
+	case <-ctx.Done():
+		err := context.Cause(ctx)
+		if !errors.Is(err, ErrProcessTimeout) {
+			mb.log.Debug("batch cancelled", KeyBatchID, batchID, KeyBatchSize, len(batch), KeyError, err)
+			return failBatch[J, R](batch, err), true, err
+		}
+		mb.log.Error("gave up on batch", KeyBatchID, batchID, KeyBatchSize, len(batch), KeyError, err)
+		return failBatch[J, R](batch, err), false, err
+	}
+}
+
+// call calls the processor, turning a batch error into a BatchError result for every job of the batch
+// and recovering a panic into a PanicError result for every job of the batch.
+// The BatchError or PanicError is returned as well.
+func (mb *MicroBatcher[J, R]) call(ctx context.Context, batchID string, batch []Job[J]) (jobResults []Result[R], err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			mb.log.Error("processor panicked", KeyBatchID, batchID, KeyBatchSize, len(batch), "panic", v)
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+			jobResults = failBatch[J, R](batch, err)
+		}
+	}()
+	jobResults, err = mb.processor.ProcessBatch(ctx, batch)
+	if err != nil {
+		mb.log.Error("processor failed batch", KeyBatchID, batchID, KeyBatchSize, len(batch), KeyError, err)
+		err = &BatchError{Err: err}
+		return failBatch[J, R](batch, err), err
+	}
+	return jobResults, nil
+}
+
+// adapt adjusts the batch size after a batch dispatched with the given size has been processed.
+func (mb *MicroBatcher[J, R]) adapt(size, n int, elapsed time.Duration, jobResults []Result[R]) {
+	failed := 0
+	for _, result := range jobResults {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	for {
+		current := mb.batchSize.Load()
+		next := int64(mb.adaptive.next(int(current), size, n, elapsed, failed))
+		if next == current || mb.batchSize.CompareAndSwap(current, next) {
+			if next != current {
+				mb.log.Info("batch size adapted", "from", current, KeyBatchSize, next)
+			}
+			return
+		}
+	}
+}
+
+// sendResults delivers the results of a batch, reporting jobs left without a result and results matching no job.
+// Failed jobs are retried instead if the retry policy allows it, otherwise they are sent to the dead letter sink.
+func (mb *MicroBatcher[J, R]) sendResults(batchID string, batch []Job[J], jobResults []Result[R]) {
+	o := mb.results.sendResults(jobIDs(batch), jobResults, mb.retryFunc(batch))
+	for _, id := range o.missing {
+		mb.log.Warn("processor returned no result for job", KeyJobID, id, KeyBatchID, batchID)
+	}
+	for _, u := range o.unmatched {
+		mb.log.Warn("processor returned unmatched result", KeyJobID, u.result.JobID, KeyBatchID, batchID, KeyError, u.err)
+		if mb.unmatchedHook != nil {
+			mb.unmatchedHook(u.result, u.err)
+		}
+	}
+	if mb.deadLetter != nil {
+		mb.sendDeadLetters(batch, o.failed)
 	}
-	close(ch)
-	return ch
+}
+
+// sendDeadLetters sends the permanently failed jobs of the batch to the dead letter sink.
+func (mb *MicroBatcher[J, R]) sendDeadLetters(batch []Job[J], failed []failure) {
+	if len(failed) == 0 {
+		return
+	}
+	jobs := make(map[JobID]Job[J], len(batch))
+	for _, job := range batch {
+		jobs[job.ID] = job
+	}
+	for _, f := range failed {
+		entry := DeadLetterEntry[J]{Job: jobs[f.jobID], Err: f.err, Attempts: f.attempts}
+		if err := mb.deadLetter.Send(entry); err != nil {
+			mb.log.Error("dead letter failed for job", KeyJobID, f.jobID, KeyError, err)
+		}
+	}
+}
+
+// retryFunc returns a func that schedules a failed job of the batch for another attempt
+// and reports whether it did, or nil if there is no retry policy.
+func (mb *MicroBatcher[J, R]) retryFunc(batch []Job[J]) func(result Result[R]) bool {
+	if mb.retryPolicy == nil {
+		return nil
+	}
+	jobs := make(map[JobID]Job[J], len(batch))
+	for _, job := range batch {
+		jobs[job.ID] = job
+	}
+	return func(result Result[R]) bool {
+		job := jobs[result.JobID]
+		if mb.ctx.Err() != nil || !mb.retryPolicy.shouldRetry(job.Attempt, result.Err) {
+			return false
+		}
+		backoff := mb.retryPolicy.backoff(job.Attempt)
+		mb.log.Info("retrying job", KeyJobID, job.ID, KeyAttempt, job.Attempt, KeyDuration, backoff, KeyError, result.Err)
+		mb.metrics.JobRetried()
+		mb.retrying.Add(1)
+		time.AfterFunc(backoff, func() {
+			weight := int64(mb.weigh(job))
+			mb.queuedWeight.Add(weight)
+			if err := mb.jobs.Add(mb.ctx, job); err != nil {
+				mb.queuedWeight.Add(-weight)
+				mb.results.abandon(job.ID, err)
+			}
+			mb.metrics.QueueDepth(mb.jobs.Len())
+			mb.retrying.Add(-1)
+			mb.notify()
+		})
+		return true
+	}
+}
+
+// withdraw removes jobs that have already been abandoned by their caller from the batch.
+func (mb *MicroBatcher[J, R]) withdraw(batch []Job[J]) []Job[J] {
+	live := batch[:0]
+	for _, job := range batch {
+		if mb.results.isPending(job.ID) {
+			live = append(live, job)
+		}
+	}
+	return live
+}
+
+// newQueue builds the jobs queue once all options have been applied.
+func (mb *MicroBatcher[J, R]) newQueue() Queue[J] {
+	if mb.queue != nil && mb.partitionKey == nil {
+		return mb.queue
+	}
+	capacity := mb.queueCapacity
+	if capacity == 0 {
+		capacity = mb.BatchSize()
+		if mb.adaptive != nil {
+			capacity = max(capacity, mb.adaptive.MaxBatchSize)
+		}
+	}
+	mb.capacity = max(capacity, 0)
+	switch {
+	case mb.partitionKey != nil:
+		mb.partitions = newPartitionQueue(mb.partitionKey, capacity)
+		return mb.partitions
+	case mb.priority:
+		return NewPriorityQueue[J](capacity, mb.maxWait)
+	case capacity < 0:
+		return NewSliceQueue[J]()
+	default:
+		return NewChanQueue[J](capacity)
+	}
+}
+
+// newLog returns the structured logger the MicroBatcher writes to, the structured logger if one is set,
+// otherwise the logger adapted to print every level as a debug message.
+func (mb *MicroBatcher[J, R]) newLog() StructuredLogger {
+	if mb.structured != nil {
+		return mb.structured
+	}
+	if l, ok := mb.logger.(noOpLogger); ok {
+		return l
+	}
+	return debugLogger{logger: mb.logger}
 }
 
 // isShutdown returns true if the MicroBatcher has been shutdown.
@@ -200,9 +883,93 @@ func (mb *MicroBatcher[J, R]) isShutdown() bool {
 	return mb.shutdownCalled.Load()
 }
 
-// isComplete returns true if there are no more jobs to process and shutdown has been called.
+// isFull returns true if the queued jobs fill a batch by count or by weight.
+// With partitioning a batch is full by count once a single partition holds the batch size.
+func (mb *MicroBatcher[J, R]) isFull() bool {
+	if mb.weigher != nil && mb.queuedWeight.Load() >= int64(mb.maxWeight) {
+		return true
+	}
+	if mb.partitions != nil {
+		_, ok := mb.partitions.full(mb.BatchSize())
+		return ok
+	}
+	return mb.jobs.Len() >= mb.BatchSize()
+}
+
+// isComplete returns true if there are no more jobs to process.
 func (mb *MicroBatcher[J, R]) isComplete() bool {
-	return mb.jobs.length() == 0
+	return mb.jobs.Len() == 0 && len(mb.carry) == 0
+}
+
+// errResult returns a closed result channel holding the given error.
+func errResult[R any](jobID JobID, err error) <-chan Result[R] {
+	ch := make(chan Result[R], 1)
+	ch <- Result[R]{
+		JobID: jobID,
+		Err:   err,
+	}
+	close(ch)
+	return ch
+}
+
+// failBatch returns a result holding err for every job of the batch.
+func failBatch[J any, R any](batch []Job[J], err error) []Result[R] {
+	jobResults := make([]Result[R], len(batch))
+	for i, job := range batch {
+		jobResults[i] = Result[R]{JobID: job.ID, Err: err}
+	}
+	return jobResults
+}
+
+// jobIDs returns the IDs of the given jobs.
+func jobIDs[J any](batch []Job[J]) []JobID {
+	ids := make([]JobID, len(batch))
+	for i, job := range batch {
+		ids[i] = job.ID
+	}
+	return ids
+}
+
+// orderResults sorts results into the order of the given job IDs, results for unknown IDs go last.
+func orderResults[R any](ids []JobID, jobResults []Result[R]) []Result[R] {
+	index := make(map[JobID]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	ordered := make([]Result[R], len(jobResults))
+	copy(ordered, jobResults)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, ok := index[ordered[i].JobID]
+		if !ok {
+			a = len(ids)
+		}
+		b, ok := index[ordered[j].JobID]
+		if !ok {
+			b = len(ids)
+		}
+		return a < b
+	})
+	return ordered
+}
+
+// contextProcessor adapts a BatchProcessor to the BatchProcessorContext interface.
+type contextProcessor[J any, R any] struct {
+	p BatchProcessor[J, R]
+}
+
+// ProcessContext processes the batch ignoring ctx.
+func (c contextProcessor[J, R]) ProcessContext(_ context.Context, batch []Job[J]) []Result[R] {
+	return c.p.Process(batch)
+}
+
+// infallibleProcessor adapts a BatchProcessorContext to the FallibleBatchProcessor interface.
+type infallibleProcessor[J any, R any] struct {
+	p BatchProcessorContext[J, R]
+}
+
+// ProcessBatch processes the batch, it never fails the batch as a whole.
+func (i infallibleProcessor[J, R]) ProcessBatch(ctx context.Context, batch []Job[J]) ([]Result[R], error) {
+	return i.p.ProcessContext(ctx, batch), nil
 }
 
 type JobID string
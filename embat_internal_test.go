@@ -0,0 +1,73 @@
+package embat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProcessor records the size of every batch it processes.
+type countingProcessor struct {
+	sizes []int
+}
+
+// Process records the batch size and returns a result for each job.
+func (p *countingProcessor) Process(batch []Job[int]) []Result[int] {
+	p.sizes = append(p.sizes, len(batch))
+	results := make([]Result[int], len(batch))
+	for i, job := range batch {
+		results[i] = NewResult(job.ID, job.Data, nil)
+	}
+	return results
+}
+
+// newTestBatcher returns a MicroBatcher backed by an unbounded queue whose start loop is not running,
+// so tests can drive processing directly.
+func newTestBatcher(p BatchProcessor[int, int], batchSize int) *MicroBatcher[int, int] {
+	ctx, cancel := context.WithCancel(context.Background())
+	mb := &MicroBatcher[int, int]{
+		processor:         infallibleProcessor[int, int]{contextProcessor[int, int]{p}},
+		maxBatchesPerTick: 1,
+		concurrency:       1,
+		sem:               make(chan struct{}, 1),
+		jobs:              &jobsS[int]{},
+		logger:            noOpLogger{},
+		log:               noOpLogger{},
+		metrics:           noOpMetrics{},
+		tracer:            noOpTracer{},
+		results:           results[int]{m: make(map[JobID]*pending[int])},
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+	mb.batchSize.Store(int64(batchSize))
+	return mb
+}
+
+// Test_processTick tests that each tick dispatches at most the configured number of batches.
+func Test_processTick(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxBatchesPerTick int
+		numJobs           int
+		sizes             []int
+	}{
+		{name: "one batch per tick", maxBatchesPerTick: 1, numJobs: 5, sizes: []int{2}},
+		{name: "capped batches per tick", maxBatchesPerTick: 2, numJobs: 5, sizes: []int{2, 2}},
+		{name: "drain backlog", maxBatchesPerTick: 0, numJobs: 5, sizes: []int{2, 2, 1}},
+		{name: "empty backlog", maxBatchesPerTick: 0, numJobs: 0, sizes: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &countingProcessor{}
+			mb := newTestBatcher(p, 2)
+			mb.maxBatchesPerTick = tt.maxBatchesPerTick
+			for i := 0; i < tt.numJobs; i++ {
+				mb.Submit(NewJob(i))
+			}
+			mb.processTick()
+			mb.workers.Wait()
+			assert.Equal(t, tt.sizes, p.sizes)
+		})
+	}
+}
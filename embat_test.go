@@ -1,12 +1,18 @@
 package embat_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	"github.com/nayanbhana/embat"
@@ -66,7 +72,7 @@ func TestMicroBatcher_Shutdown(t *testing.T) {
 
 	select {
 	case result := <-newResultCh:
-		assert.Error(t, result.Err)
+		assert.ErrorIs(t, result.Err, embat.ErrShutdown)
 	case <-time.After(100 * time.Millisecond):
 		t.Error("expected result not received in time")
 	}
@@ -135,3 +141,1679 @@ func TestMicroBatcher_Shutdown_completes_all_jobs(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestMicroBatcher_SubmitContext_withdraws_job tests that a queued job is withdrawn when its context is cancelled.
+func TestMicroBatcher_SubmitContext_withdraws_job(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().Process(gomock.Any()).Times(0)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](50*time.Millisecond),
+		embat.WithBatchSize[string, int](2),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultCh := mb.SubmitContext(ctx, embat.NewJob("test-job"))
+	cancel()
+
+	select {
+	case result := <-resultCh:
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	case <-time.After(100 * time.Millisecond):
+		t.Error("expected result not received in time")
+	}
+
+	// Give the batcher a few ticks to make sure the withdrawn job is never processed.
+	time.Sleep(150 * time.Millisecond)
+	mb.Shutdown()
+}
+
+// TestMicroBatcher_SubmitContext_done tests that a job submitted with a done context is rejected.
+func TestMicroBatcher_SubmitContext_done(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mb := embat.NewMicroBatcher[string, int](mbp)
+	defer mb.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	result := <-mb.SubmitContext(ctx, embat.NewJob("test-job"))
+	assert.ErrorIs(t, result.Err, context.DeadlineExceeded)
+}
+
+// TestMicroBatcher_ProcessContext_cancelled tests that the batch context is cancelled when all its jobs are abandoned.
+func TestMicroBatcher_ProcessContext_cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	batchErr := make(chan error, 1)
+	mbp := mock.NewMockBatchProcessorContext[string, int](ctrl)
+	mbp.EXPECT().
+		ProcessContext(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, jobs []embat.Job[string]) []embat.Result[int] {
+			close(started)
+			select {
+			case <-ctx.Done():
+				batchErr <- ctx.Err()
+			case <-time.After(time.Second):
+				batchErr <- nil
+			}
+			return nil
+		}).Times(1)
+
+	mb := embat.NewMicroBatcherContext[string, int](
+		mbp,
+		embat.WithFrequency[string, int](50*time.Millisecond),
+		embat.WithBatchSize[string, int](2),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultCh := mb.SubmitContext(ctx, embat.NewJob("test-job"))
+	<-started
+	cancel()
+
+	result := <-resultCh
+	assert.ErrorIs(t, result.Err, context.Canceled)
+	assert.ErrorIs(t, <-batchErr, context.Canceled)
+	mb.Shutdown()
+}
+
+// TestMicroBatcher_Shutdown_waits_for_jobs tests that shutdown returns only after queued jobs are processed.
+func TestMicroBatcher_Shutdown_waits_for_jobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, 42, nil))
+			}
+			return results
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+	)
+
+	var resultChs []<-chan embat.Result[int]
+	for i := 0; i < 2; i++ {
+		resultChs = append(resultChs, mb.Submit(embat.NewJob(fmt.Sprintf("test-job-%v", i))))
+	}
+	// The queue is full, so this submission blocks until shutdown starts draining.
+	blocked := make(chan (<-chan embat.Result[int]), 1)
+	go func() {
+		blocked <- mb.Submit(embat.NewJob("test-job-2"))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	mb.Shutdown()
+	resultChs = append(resultChs, <-blocked)
+
+	for _, resultCh := range resultChs {
+		select {
+		case result := <-resultCh:
+			assert.NoError(t, result.Err)
+			assert.Equal(t, 42, result.Result)
+		default:
+			t.Error("expected result to be available after shutdown")
+		}
+	}
+}
+
+// TestMicroBatcher_Shutdown_concurrent tests that shutdown can be called repeatedly from multiple goroutines.
+func TestMicroBatcher_Shutdown_concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mb := embat.NewMicroBatcher[string, int](mbp)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mb.Shutdown()
+		}()
+	}
+	wg.Wait()
+	assert.NotPanics(t, mb.Shutdown)
+}
+
+// TestMicroBatcher_ShutdownContext_deadline tests that pending jobs are failed when shutdown gives up.
+func TestMicroBatcher_ShutdownContext_deadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	mbp := mock.NewMockBatchProcessorContext[string, int](ctrl)
+	mbp.EXPECT().
+		ProcessContext(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, jobs []embat.Job[string]) []embat.Result[int] {
+			close(started)
+			<-ctx.Done()
+			return nil
+		}).Times(1)
+
+	mb := embat.NewMicroBatcherContext[string, int](
+		mbp,
+		embat.WithFrequency[string, int](10*time.Millisecond),
+		embat.WithBatchSize[string, int](1),
+	)
+
+	resultCh := mb.Submit(embat.NewJob("test-job"))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, mb.ShutdownContext(ctx), context.DeadlineExceeded)
+
+	result := <-resultCh
+	assert.ErrorIs(t, result.Err, embat.ErrShutdownAborted)
+}
+
+// TestMicroBatcher_ShutdownContext_blocked_submit tests that a submission waiting for room in the queue
+// gives up once shutdown has been aborted, even though the processor is still busy.
+func TestMicroBatcher_ShutdownContext_blocked_submit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	defer close(release)
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			<-release
+			return nil
+		}).AnyTimes()
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+	)
+	mb.Submit(embat.NewJob("in-flight"))
+	time.Sleep(50 * time.Millisecond)
+	mb.Submit(embat.NewJob("queued"))
+	blockedCh := make(chan (<-chan embat.Result[int]), 1)
+	go func() { blockedCh <- mb.Submit(embat.NewJob("blocked")) }()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, mb.ShutdownContext(ctx), context.DeadlineExceeded)
+
+	select {
+	case resultCh := <-blockedCh:
+		assert.ErrorIs(t, (<-resultCh).Err, embat.ErrShutdownAborted)
+	case <-time.After(time.Second):
+		t.Fatal("submission still blocked after shutdown was aborted")
+	}
+}
+
+// TestMicroBatcher_Submit_full_batch tests that a full batch is processed without waiting for the ticker.
+func TestMicroBatcher_Submit_full_batch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(2)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, 42, nil))
+			}
+			return results
+		}).Times(1)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+	)
+	defer mb.Shutdown()
+
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("test-job-1")),
+		mb.Submit(embat.NewJob("test-job-2")),
+	}
+	for _, resultCh := range resultChs {
+		select {
+		case result := <-resultCh:
+			assert.NoError(t, result.Err)
+		case <-time.After(100 * time.Millisecond):
+			t.Error("expected result not received in time")
+		}
+	}
+}
+
+// TestMicroBatcher_WithConcurrency tests that multiple batches can be processed at once.
+func TestMicroBatcher_WithConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var inFlight sync.WaitGroup
+	inFlight.Add(2)
+	mbp := mock.NewMockBatchProcessorContext[string, int](ctrl)
+	mbp.EXPECT().
+		ProcessContext(gomock.Any(), gomock.Len(1)).
+		DoAndReturn(func(ctx context.Context, jobs []embat.Job[string]) []embat.Result[int] {
+			// Both batches must be in flight at the same time for either to finish.
+			inFlight.Done()
+			inFlight.Wait()
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	mb := embat.NewMicroBatcherContext[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithConcurrency[string, int](2),
+	)
+
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("test-job-1")),
+		mb.Submit(embat.NewJob("test-job-2")),
+	}
+	for _, resultCh := range resultChs {
+		select {
+		case result := <-resultCh:
+			assert.NoError(t, result.Err)
+		case <-time.After(time.Second):
+			t.Error("expected result not received in time")
+		}
+	}
+	mb.Shutdown()
+}
+
+// TestMicroBatcher_WithOrderedResults tests that results are delivered in submission order.
+func TestMicroBatcher_WithOrderedResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(1)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			// The first job finishes last.
+			if jobs[0].Data == "test-job-1" {
+				time.Sleep(100 * time.Millisecond)
+			}
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithConcurrency[string, int](2),
+		embat.WithOrderedResults[string, int](),
+	)
+
+	firstCh := mb.Submit(embat.NewJob("test-job-1"))
+	secondCh := mb.Submit(embat.NewJob("test-job-2"))
+
+	// The second job finishes first, but its result must not be delivered before the first one.
+	second := <-secondCh
+	assert.NoError(t, second.Err)
+	select {
+	case first := <-firstCh:
+		assert.NoError(t, first.Err)
+	default:
+		t.Error("expected first result to be delivered before the second")
+	}
+	mb.Shutdown()
+}
+
+// TestMicroBatcher_overflow tests the overflow policies when the queue is full.
+func TestMicroBatcher_overflow(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  embat.Option[string, int]
+		// overflowErr is the error expected for the overflowing job.
+		overflowErr error
+		// droppedErr is the error expected for the oldest queued job.
+		droppedErr error
+	}{
+		{
+			name:        "fail",
+			opt:         embat.WithOverflowPolicy[string, int](embat.OverflowFail),
+			overflowErr: embat.ErrQueueFull,
+		},
+		{
+			name:        "block with timeout",
+			opt:         embat.WithOverflowTimeout[string, int](50 * time.Millisecond),
+			overflowErr: embat.ErrQueueFull,
+		},
+		{
+			name:       "drop oldest",
+			opt:        embat.WithOverflowPolicy[string, int](embat.OverflowDropOldest),
+			droppedErr: embat.ErrDropped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			release := make(chan struct{})
+			mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+			mbp.EXPECT().
+				Process(gomock.Any()).
+				DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+					<-release
+					var results []embat.Result[int]
+					for _, job := range jobs {
+						results = append(results, embat.NewResult(job.ID, 42, nil))
+					}
+					return results
+				}).AnyTimes()
+
+			mb := embat.NewMicroBatcher[string, int](
+				mbp,
+				embat.WithFrequency[string, int](time.Hour),
+				embat.WithBatchSize[string, int](2),
+				tt.opt,
+			)
+
+			// The first batch blocks the processor, the next two jobs fill the queue.
+			mb.Submit(embat.NewJob("in-flight-1"))
+			mb.Submit(embat.NewJob("in-flight-2"))
+			time.Sleep(50 * time.Millisecond)
+			oldestCh := mb.Submit(embat.NewJob("queued-1"))
+			mb.Submit(embat.NewJob("queued-2"))
+
+			overflowCh := mb.Submit(embat.NewJob("overflow"))
+			if tt.droppedErr != nil {
+				result := <-oldestCh
+				assert.ErrorIs(t, result.Err, tt.droppedErr)
+			}
+			close(release)
+			result := <-overflowCh
+			if tt.overflowErr != nil {
+				assert.ErrorIs(t, result.Err, tt.overflowErr)
+			} else {
+				assert.NoError(t, result.Err)
+			}
+			mb.Shutdown()
+		})
+	}
+}
+
+// TestMicroBatcher_TrySubmit tests that TrySubmit reports acceptance synchronously.
+func TestMicroBatcher_TrySubmit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			<-release
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+	)
+
+	inFlightCh, err := mb.TrySubmit(embat.NewJob("in-flight"))
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	queuedCh, err := mb.TrySubmit(embat.NewJob("queued"))
+	assert.NoError(t, err)
+
+	resultCh, err := mb.TrySubmit(embat.NewJob("overflow"))
+	assert.ErrorIs(t, err, embat.ErrQueueFull)
+	assert.Nil(t, resultCh)
+
+	close(release)
+	assert.NoError(t, (<-inFlightCh).Err)
+	assert.NoError(t, (<-queuedCh).Err)
+	mb.Shutdown()
+
+	_, err = mb.TrySubmit(embat.NewJob("after-shutdown"))
+	assert.ErrorIs(t, err, embat.ErrShutdown)
+}
+
+// TestMicroBatcher_TrySubmit_during_shutdown tests that submissions made while shutdown waits for a submission
+// blocked on a full queue are rejected straight away.
+func TestMicroBatcher_TrySubmit_during_shutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			<-release
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(3)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+	)
+	inFlightCh, err := mb.TrySubmit(embat.NewJob("in-flight"))
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	queuedCh, err := mb.TrySubmit(embat.NewJob("queued"))
+	require.NoError(t, err)
+	blockedCh := make(chan (<-chan embat.Result[int]), 1)
+	go func() { blockedCh <- mb.Submit(embat.NewJob("blocked")) }()
+	time.Sleep(50 * time.Millisecond)
+	shutdown := make(chan struct{})
+	go func() {
+		mb.Shutdown()
+		close(shutdown)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	rejected := make(chan error, 2)
+	go func() {
+		_, err := mb.TrySubmit(embat.NewJob("try-submit"))
+		rejected <- err
+		rejected <- (<-mb.Submit(embat.NewJob("submit"))).Err
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-rejected:
+			assert.ErrorIs(t, err, embat.ErrShutdown)
+		case <-time.After(time.Second):
+			t.Fatal("submission blocked during shutdown")
+		}
+	}
+
+	close(release)
+	<-shutdown
+	assert.NoError(t, (<-inFlightCh).Err)
+	assert.NoError(t, (<-queuedCh).Err)
+	assert.NoError(t, (<-<-blockedCh).Err)
+}
+
+// TestMicroBatcher_WithQueueCapacity tests that the queue capacity is independent of the batch size and option order.
+func TestMicroBatcher_WithQueueCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		accepted int
+	}{
+		{name: "bounded", capacity: 3, accepted: 3},
+		{name: "unbounded", capacity: 0, accepted: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			release := make(chan struct{})
+			mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+			mbp.EXPECT().
+				Process(gomock.Any()).
+				DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+					<-release
+					return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+				}).Times(tt.accepted + 1)
+
+			mb := embat.NewMicroBatcher[string, int](
+				mbp,
+				embat.WithQueueCapacity[string, int](tt.capacity),
+				embat.WithFrequency[string, int](time.Hour),
+				embat.WithBatchSize[string, int](1),
+			)
+
+			// The first job blocks the processor so the rest stay queued.
+			_, err := mb.TrySubmit(embat.NewJob("in-flight"))
+			assert.NoError(t, err)
+			time.Sleep(50 * time.Millisecond)
+			for i := 0; i < tt.accepted; i++ {
+				_, err := mb.TrySubmit(embat.NewJob(fmt.Sprintf("queued-%v", i)))
+				assert.NoError(t, err)
+			}
+			if tt.capacity > 0 {
+				_, err := mb.TrySubmit(embat.NewJob("overflow"))
+				assert.ErrorIs(t, err, embat.ErrQueueFull)
+			}
+			close(release)
+			mb.Shutdown()
+		})
+	}
+}
+
+// TestMicroBatcher_unmatched_results tests that every job gets a result even if the processor omits it,
+// and that results matching no job are reported.
+func TestMicroBatcher_unmatched_results(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(2)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			return []embat.Result[int]{
+				embat.NewResult(jobs[0].ID, 42, nil),
+				embat.NewResult(jobs[0].ID, 43, nil),
+				embat.NewResult("unknown", 44, nil),
+			}
+		}).Times(1)
+
+	reported := make(chan error, 2)
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithUnmatchedResultHook[string, int](func(result embat.Result[int], err error) {
+			reported <- err
+		}),
+	)
+
+	answeredCh := mb.Submit(embat.NewJob("answered"))
+	omittedCh := mb.Submit(embat.NewJob("omitted"))
+
+	answered := <-answeredCh
+	assert.NoError(t, answered.Err)
+	assert.Equal(t, 42, answered.Result)
+	assert.ErrorIs(t, (<-omittedCh).Err, embat.ErrNoResult)
+	mb.Shutdown()
+
+	assert.ErrorIs(t, <-reported, embat.ErrDuplicateResult)
+	assert.ErrorIs(t, <-reported, embat.ErrUnknownJob)
+}
+
+// TestMicroBatcher_processor_panic tests that a panicking processor fails its batch and the batcher keeps running.
+func TestMicroBatcher_processor_panic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	gomock.InOrder(
+		mbp.EXPECT().
+			Process(gomock.Any()).
+			DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+				panic("boom")
+			}),
+		mbp.EXPECT().
+			Process(gomock.Any()).
+			DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+				return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+			}),
+	)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+	)
+
+	result := <-mb.Submit(embat.NewJob("panics"))
+	var panicErr *embat.PanicError
+	if assert.ErrorAs(t, result.Err, &panicErr) {
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.NotEmpty(t, panicErr.Stack)
+	}
+
+	result = <-mb.Submit(embat.NewJob("succeeds"))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 42, result.Result)
+	mb.Shutdown()
+}
+
+// TestMicroBatcher_WithRetryPolicy tests that failed jobs are retried in a later batch.
+func TestMicroBatcher_WithRetryPolicy(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		wantErr  error
+	}{
+		{name: "succeeds on retry", errs: []error{errTransient, nil}, attempts: 2},
+		{name: "attempts exhausted", errs: []error{errTransient, errTransient, errTransient}, attempts: 3, wantErr: errTransient},
+		{name: "not retryable", errs: []error{errPermanent}, attempts: 1, wantErr: errPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+			mbp.EXPECT().
+				Process(gomock.Len(1)).
+				DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+					return []embat.Result[int]{embat.NewResult(jobs[0].ID, jobs[0].Attempt, tt.errs[jobs[0].Attempt-1])}
+				}).Times(tt.attempts)
+
+			mb := embat.NewMicroBatcher[string, int](
+				mbp,
+				embat.WithFrequency[string, int](time.Hour),
+				embat.WithBatchSize[string, int](1),
+				embat.WithRetryPolicy[string, int](embat.RetryPolicy{
+					MaxAttempts:    3,
+					InitialBackoff: 10 * time.Millisecond,
+					Retryable: func(err error) bool {
+						return errors.Is(err, errTransient)
+					},
+				}),
+			)
+
+			result := <-mb.Submit(embat.NewJob("test-job"))
+			assert.Equal(t, tt.attempts, result.Result)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, result.Err, tt.wantErr)
+			} else {
+				assert.NoError(t, result.Err)
+			}
+			mb.Shutdown()
+		})
+	}
+}
+
+// TestMicroBatcher_WithRetryPolicy_shutdown tests that shutdown waits for jobs waiting to be retried.
+func TestMicroBatcher_WithRetryPolicy_shutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(1)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			if jobs[0].Attempt == 1 {
+				return []embat.Result[int]{embat.NewResult(jobs[0].ID, 0, errors.New("transient"))}
+			}
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithRetryPolicy[string, int](embat.RetryPolicy{
+			MaxAttempts:    2,
+			InitialBackoff: 50 * time.Millisecond,
+		}),
+	)
+
+	resultCh := mb.Submit(embat.NewJob("test-job"))
+	time.Sleep(10 * time.Millisecond)
+	mb.Shutdown()
+
+	select {
+	case result := <-resultCh:
+		assert.NoError(t, result.Err)
+		assert.Equal(t, 42, result.Result)
+	default:
+		t.Error("expected result to be available after shutdown")
+	}
+}
+
+// TestMicroBatcher_FallibleBatchProcessor tests that a batch error fails every job and can be retried.
+func TestMicroBatcher_FallibleBatchProcessor(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	tests := []struct {
+		name  string
+		opts  []embat.Option[string, int]
+		calls int
+		want  error
+	}{
+		{name: "batch error", calls: 1, want: errUnavailable},
+		{
+			name: "batch retried",
+			opts: []embat.Option[string, int]{
+				embat.WithRetryPolicy[string, int](embat.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+			},
+			calls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mbp := mock.NewMockFallibleBatchProcessor[string, int](ctrl)
+			mbp.EXPECT().
+				ProcessBatch(gomock.Any(), gomock.Len(2)).
+				DoAndReturn(func(ctx context.Context, jobs []embat.Job[string]) ([]embat.Result[int], error) {
+					if jobs[0].Attempt == 1 {
+						return nil, errUnavailable
+					}
+					var results []embat.Result[int]
+					for _, job := range jobs {
+						results = append(results, embat.NewResult(job.ID, 42, nil))
+					}
+					return results, nil
+				}).Times(tt.calls)
+
+			mb := embat.NewFallibleMicroBatcher[string, int](
+				mbp,
+				append([]embat.Option[string, int]{
+					embat.WithFrequency[string, int](time.Hour),
+					embat.WithBatchSize[string, int](2),
+				}, tt.opts...)...,
+			)
+
+			resultChs := []<-chan embat.Result[int]{
+				mb.Submit(embat.NewJob("test-job-1")),
+				mb.Submit(embat.NewJob("test-job-2")),
+			}
+			for _, resultCh := range resultChs {
+				result := <-resultCh
+				if tt.want == nil {
+					assert.NoError(t, result.Err)
+					continue
+				}
+				var batchErr *embat.BatchError
+				assert.ErrorAs(t, result.Err, &batchErr)
+				assert.ErrorIs(t, result.Err, tt.want)
+			}
+			mb.Shutdown()
+		})
+	}
+}
+
+// TestMicroBatcher_WithDeadLetter tests that jobs which exhaust their retries are sent to the dead letter sink.
+func TestMicroBatcher_WithDeadLetter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				if job.Data == "fails" {
+					results = append(results, embat.NewResult(job.ID, 0, fmt.Errorf("attempt %d failed", job.Attempt)))
+				} else {
+					results = append(results, embat.NewResult(job.ID, 42, nil))
+				}
+			}
+			return results
+		}).AnyTimes()
+
+	dl := embat.NewMemoryDeadLetter[string]()
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](10*time.Millisecond),
+		embat.WithBatchSize[string, int](2),
+		embat.WithRetryPolicy[string, int](embat.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		embat.WithDeadLetter[string, int](dl),
+	)
+
+	failed := embat.NewJob("fails")
+	failedCh := mb.Submit(failed)
+	succeededCh := mb.Submit(embat.NewJob("succeeds"))
+	assert.EqualError(t, (<-failedCh).Err, "attempt 2 failed")
+	assert.NoError(t, (<-succeededCh).Err)
+	mb.Shutdown()
+
+	entries := dl.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, failed.ID, entries[0].Job.ID)
+	assert.Equal(t, 2, entries[0].Job.Attempt)
+	assert.EqualError(t, entries[0].Err, "attempt 2 failed")
+	require.Len(t, entries[0].Attempts, 2)
+	assert.EqualError(t, entries[0].Attempts[0].Err, "attempt 1 failed")
+	assert.Equal(t, 1, entries[0].Attempts[0].Attempt)
+}
+
+// TestMicroBatcher_WithProcessTimeout tests that a hung processor fails its batch and the batcher moves on.
+func TestMicroBatcher_WithProcessTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	defer close(release)
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(1)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			if jobs[0].Data == "hangs" {
+				// Ignores the deadline and never returns while the test runs.
+				<-release
+			}
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithProcessTimeout[string, int](50*time.Millisecond),
+	)
+
+	result := <-mb.Submit(embat.NewJob("hangs"))
+	assert.ErrorIs(t, result.Err, embat.ErrProcessTimeout)
+
+	result = <-mb.Submit(embat.NewJob("succeeds"))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 42, result.Result)
+	mb.Shutdown()
+}
+
+// TestMicroBatcher_WithProcessTimeout_abandoned tests that a batch given up on because its jobs were abandoned
+// is not mistaken for a timed out batch, which would shrink the adaptive batch size.
+func TestMicroBatcher_WithProcessTimeout_abandoned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	mbp := mock.NewMockBatchProcessorContext[string, int](ctrl)
+	mbp.EXPECT().
+		ProcessContext(gomock.Any(), gomock.Len(2)).
+		DoAndReturn(func(ctx context.Context, jobs []embat.Job[string]) []embat.Result[int] {
+			close(started)
+			<-ctx.Done()
+			return nil
+		}).Times(1)
+
+	mb := embat.NewMicroBatcherContext[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithProcessTimeout[string, int](time.Hour),
+		embat.WithAdaptiveBatchSize[string, int](embat.AdaptiveBatchSize{
+			MinBatchSize:  1,
+			MaxBatchSize:  4,
+			TargetLatency: time.Hour,
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultChs := []<-chan embat.Result[int]{
+		mb.SubmitContext(ctx, embat.NewJob("test-job-1")),
+		mb.SubmitContext(ctx, embat.NewJob("test-job-2")),
+	}
+	<-started
+	cancel()
+	for _, resultCh := range resultChs {
+		assert.ErrorIs(t, (<-resultCh).Err, context.Canceled)
+	}
+	mb.Shutdown()
+	assert.Equal(t, 2, mb.BatchSize())
+}
+
+// TestMicroBatcher_WithAdaptiveBatchSize tests that the batch size grows while batches are fast and shrinks when they fail.
+func TestMicroBatcher_WithAdaptiveBatchSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var fail atomic.Bool
+	mbp := mock.NewMockBatchProcessor[int, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[int]) []embat.Result[int] {
+			var err error
+			if fail.Load() {
+				err = errors.New("failed")
+			}
+			results := make([]embat.Result[int], len(jobs))
+			for i, job := range jobs {
+				results[i] = embat.NewResult(job.ID, job.Data, err)
+			}
+			return results
+		}).AnyTimes()
+
+	mb := embat.NewMicroBatcher[int, int](
+		mbp,
+		embat.WithFrequency[int, int](time.Hour),
+		embat.WithBatchSize[int, int](2),
+		embat.WithAdaptiveBatchSize[int, int](embat.AdaptiveBatchSize{
+			MinBatchSize:  1,
+			MaxBatchSize:  4,
+			TargetLatency: time.Second,
+		}),
+	)
+	defer mb.Shutdown()
+
+	submitBatch := func() {
+		size := mb.BatchSize()
+		chs := make([]<-chan embat.Result[int], size)
+		for i := range chs {
+			chs[i] = mb.Submit(embat.NewJob(i))
+		}
+		for _, ch := range chs {
+			<-ch
+		}
+	}
+	for i := 0; i < 5; i++ {
+		submitBatch()
+	}
+	assert.Equal(t, 4, mb.BatchSize())
+
+	fail.Store(true)
+	submitBatch()
+	assert.Equal(t, 2, mb.BatchSize())
+}
+
+// TestMicroBatcher_WithAdaptiveBatchSize_queue_capacity tests that without a maximum the batch size only grows
+// as far as the queue capacity, so full batches keep being dispatched without waiting for the ticker.
+func TestMicroBatcher_WithAdaptiveBatchSize_queue_capacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []embat.Option[int, int]
+		wantSize int
+	}{
+		{name: "default capacity", wantSize: 4},
+		{name: "larger capacity", opts: []embat.Option[int, int]{embat.WithQueueCapacity[int, int](6)}, wantSize: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mbp := mock.NewMockBatchProcessor[int, int](ctrl)
+			mbp.EXPECT().
+				Process(gomock.Any()).
+				DoAndReturn(func(jobs []embat.Job[int]) []embat.Result[int] {
+					results := make([]embat.Result[int], len(jobs))
+					for i, job := range jobs {
+						results[i] = embat.NewResult(job.ID, job.Data, nil)
+					}
+					return results
+				}).AnyTimes()
+
+			mb := embat.NewMicroBatcher[int, int](
+				mbp,
+				append([]embat.Option[int, int]{
+					embat.WithFrequency[int, int](time.Hour),
+					embat.WithBatchSize[int, int](4),
+					embat.WithAdaptiveBatchSize[int, int](embat.AdaptiveBatchSize{TargetLatency: time.Second}),
+				}, tt.opts...)...,
+			)
+			defer mb.Shutdown()
+
+			for round := 0; round < 4; round++ {
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					chs := make([]<-chan embat.Result[int], mb.BatchSize())
+					for i := range chs {
+						chs[i] = mb.Submit(embat.NewJob(i))
+					}
+					for _, ch := range chs {
+						<-ch
+					}
+				}()
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatalf("round %d waited for the ticker with batch size %d", round, mb.BatchSize())
+				}
+			}
+			assert.Equal(t, tt.wantSize, mb.BatchSize())
+		})
+	}
+}
+
+// TestMicroBatcher_SetBatchSize tests that a smaller batch size dispatches the jobs already queued.
+func TestMicroBatcher_SetBatchSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(2)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, 42, nil))
+			}
+			return results
+		}).Times(1)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](3),
+	)
+	defer mb.Shutdown()
+
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("test-job-1")),
+		mb.Submit(embat.NewJob("test-job-2")),
+	}
+	assert.ErrorIs(t, mb.SetBatchSize(0), embat.ErrInvalidBatchSize)
+	assert.ErrorIs(t, mb.SetBatchSize(4), embat.ErrBatchSizeExceedsCapacity)
+	assert.Equal(t, 3, mb.BatchSize())
+	assert.NoError(t, mb.SetBatchSize(2))
+	assert.Equal(t, 2, mb.BatchSize())
+	for _, resultCh := range resultChs {
+		select {
+		case result := <-resultCh:
+			assert.NoError(t, result.Err)
+		case <-time.After(time.Second):
+			t.Error("expected result not received in time")
+		}
+	}
+}
+
+// TestMicroBatcher_SetFrequency tests that a new frequency resets the ticker.
+func TestMicroBatcher_SetFrequency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(1)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(1)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](10),
+	)
+	defer mb.Shutdown()
+
+	resultCh := mb.Submit(embat.NewJob("test-job"))
+	assert.ErrorIs(t, mb.SetFrequency(0), embat.ErrInvalidFrequency)
+	assert.NoError(t, mb.SetFrequency(10*time.Millisecond))
+	assert.Equal(t, 10*time.Millisecond, mb.Frequency())
+	select {
+	case result := <-resultCh:
+		assert.NoError(t, result.Err)
+	case <-time.After(time.Second):
+		t.Error("expected result not received in time")
+	}
+}
+
+// TestMicroBatcher_WithBatchWeigher tests that a full batch by weight is dispatched and oversize jobs are rejected.
+func TestMicroBatcher_WithBatchWeigher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(2)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, len(job.Data), nil))
+			}
+			return results
+		}).Times(1)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](10),
+		embat.WithBatchWeigher[string, int](func(job embat.Job[string]) int {
+			return len(job.Data)
+		}, 8),
+	)
+	defer mb.Shutdown()
+
+	_, err := mb.TrySubmit(embat.NewJob("far-too-heavy"))
+	assert.ErrorIs(t, err, embat.ErrOversize)
+
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("four")),
+		mb.Submit(embat.NewJob("four")),
+	}
+	for _, resultCh := range resultChs {
+		select {
+		case result := <-resultCh:
+			assert.NoError(t, result.Err)
+		case <-time.After(time.Second):
+			t.Error("expected result not received in time")
+		}
+	}
+}
+
+// TestMicroBatcher_WithPartitionKey tests that every batch holds jobs of a single partition.
+func TestMicroBatcher_WithPartitionKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				// Every job of the batch must belong to the partition of the first job.
+				if job.Data != jobs[0].Data {
+					results = append(results, embat.NewResult(job.ID, 0, errors.New("mixed partitions")))
+					continue
+				}
+				results = append(results, embat.NewResult(job.ID, len(jobs), nil))
+			}
+			return results
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithQueueCapacity[string, int](10),
+		embat.WithPartitionKey[string, int](func(job embat.Job[string]) string {
+			return job.Data
+		}),
+	)
+
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("tenant-a")),
+		mb.Submit(embat.NewJob("tenant-b")),
+		mb.Submit(embat.NewJob("tenant-a")),
+	}
+	// Partition tenant-a is full and dispatched straight away.
+	for _, i := range []int{0, 2} {
+		select {
+		case result := <-resultChs[i]:
+			assert.NoError(t, result.Err)
+			assert.Equal(t, 2, result.Result)
+		case <-time.After(time.Second):
+			t.Error("expected result not received in time")
+		}
+	}
+	mb.Shutdown()
+	result := <-resultChs[1]
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 1, result.Result)
+}
+
+// TestMicroBatcher_WithPartitionKey_time_bound tests that a partition that keeps filling up does not hold back
+// the partial batch of another partition beyond the frequency.
+func TestMicroBatcher_WithPartitionKey_time_bound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, 42, nil))
+			}
+			return results
+		}).AnyTimes()
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](100*time.Millisecond),
+		embat.WithBatchSize[string, int](2),
+		embat.WithQueueCapacity[string, int](10),
+		embat.WithPartitionKey[string, int](func(job embat.Job[string]) string {
+			return job.Data
+		}),
+	)
+	defer mb.Shutdown()
+
+	stop := make(chan struct{})
+	hot := make(chan struct{})
+	go func() {
+		defer close(hot)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(40 * time.Millisecond):
+				mb.Submit(embat.NewJob("hot"))
+				mb.Submit(embat.NewJob("hot"))
+			}
+		}
+	}()
+	started := time.Now()
+	resultCh := mb.Submit(embat.NewJob("cold"))
+	select {
+	case result := <-resultCh:
+		assert.NoError(t, result.Err)
+		assert.Less(t, time.Since(started), 300*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Error("partial batch of the cold partition was held back by the hot partition")
+	}
+	close(stop)
+	<-hot
+}
+
+// TestMicroBatcher_SubmitWithPriority tests that higher priority jobs are placed into the next batch first.
+func TestMicroBatcher_SubmitWithPriority(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	batches := make(chan []string, 3)
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var batch []string
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				batch = append(batch, job.Data)
+				results = append(results, embat.NewResult(job.ID, job.Priority, nil))
+			}
+			if jobs[0].Data == "blocker" {
+				close(started)
+				<-release
+			}
+			batches <- batch
+			return results
+		}).Times(3)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithQueueCapacity[string, int](10),
+		embat.WithPriority[string, int](time.Minute),
+	)
+
+	mb.Submit(embat.NewJob("blocker"))
+	mb.Submit(embat.NewJob("blocker"))
+	<-started
+	// The backfill jobs wait in the queue while the only worker is busy.
+	mb.Submit(embat.NewJob("backfill-1"))
+	mb.Submit(embat.NewJob("backfill-2"))
+	result := mb.SubmitWithPriority(embat.NewJob("urgent"), 10)
+	close(release)
+	mb.Shutdown()
+
+	assert.Equal(t, []string{"blocker", "blocker"}, <-batches)
+	assert.Equal(t, []string{"urgent", "backfill-1"}, <-batches)
+	assert.Equal(t, []string{"backfill-2"}, <-batches)
+	assert.Equal(t, 10, (<-result).Result)
+}
+
+// TestMicroBatcher_WithPriority_drop_oldest tests that a full priority queue drops its oldest job,
+// not its most urgent one.
+func TestMicroBatcher_WithPriority_drop_oldest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			if jobs[0].Data == "blocker" {
+				close(started)
+				<-release
+			}
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, job.Priority, nil))
+			}
+			return results
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithPriority[string, int](time.Minute),
+		embat.WithOverflowPolicy[string, int](embat.OverflowDropOldest),
+	)
+
+	mb.Submit(embat.NewJob("blocker"))
+	mb.Submit(embat.NewJob("blocker"))
+	<-started
+	oldCh := mb.SubmitWithPriority(embat.NewJob("low-old"), 0)
+	highCh := mb.SubmitWithPriority(embat.NewJob("high-new"), 10)
+	newCh := mb.SubmitWithPriority(embat.NewJob("mid-newest"), 5)
+	assert.ErrorIs(t, (<-oldCh).Err, embat.ErrDropped)
+	close(release)
+	mb.Shutdown()
+
+	assert.Equal(t, 10, (<-highCh).Result)
+	assert.Equal(t, 5, (<-newCh).Result)
+}
+
+// TestMicroBatcher_WithDeduplication tests that identical pending jobs are processed once and the result reused.
+func TestMicroBatcher_WithDeduplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(2)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, len(job.Data), nil))
+			}
+			return results
+		}).Times(1)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithDeduplication[string, int](func(job embat.Job[string]) string {
+			return job.Data
+		}, time.Minute),
+	)
+	defer mb.Shutdown()
+
+	jobs := []embat.Job[string]{embat.NewJob("a"), embat.NewJob("a"), embat.NewJob("bb")}
+	var resultChs []<-chan embat.Result[int]
+	for _, job := range jobs {
+		resultChs = append(resultChs, mb.Submit(job))
+	}
+	for i, resultCh := range resultChs {
+		select {
+		case result := <-resultCh:
+			assert.NoError(t, result.Err)
+			assert.Equal(t, jobs[i].ID, result.JobID)
+			assert.Equal(t, len(jobs[i].Data), result.Result)
+		case <-time.After(time.Second):
+			t.Error("expected result not received in time")
+		}
+	}
+
+	// The completed result is reused without processing the job again.
+	job := embat.NewJob("a")
+	result := <-mb.Submit(job)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, job.ID, result.JobID)
+	assert.Equal(t, 1, result.Result)
+}
+
+// TestMicroBatcher_WithDeduplication_rejected tests that a deduplicated job that could not be queued
+// is counted as rejected only, not as completed.
+func TestMicroBatcher_WithDeduplication_rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			if jobs[0].Data == "in-flight" {
+				close(started)
+				<-release
+			}
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	m := embat.NewMemoryMetrics()
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithOverflowPolicy[string, int](embat.OverflowFail),
+		embat.WithDeduplication[string, int](func(job embat.Job[string]) string { return job.Data }, 0),
+		embat.WithMetrics[string, int](m),
+	)
+	inFlightCh, err := mb.TrySubmit(embat.NewJob("in-flight"))
+	require.NoError(t, err)
+	<-started
+	queuedCh, err := mb.TrySubmit(embat.NewJob("queued"))
+	require.NoError(t, err)
+	_, err = mb.TrySubmit(embat.NewJob("overflow"))
+	assert.ErrorIs(t, err, embat.ErrQueueFull)
+
+	close(release)
+	mb.Shutdown()
+	assert.NoError(t, (<-inFlightCh).Err)
+	assert.NoError(t, (<-queuedCh).Err)
+
+	s := mb.Stats()
+	assert.Equal(t, uint64(2), s.Processed)
+	assert.Equal(t, uint64(0), s.Failed)
+	assert.Equal(t, uint64(1), s.Rejected)
+	snapshot := m.Snapshot()
+	assert.Equal(t, uint64(2), snapshot.Completed)
+	assert.Equal(t, uint64(0), snapshot.Failed)
+	assert.Equal(t, uint64(1), snapshot.Rejected)
+}
+
+// TestMicroBatcher_WithStructuredLogger tests that rejected jobs are logged as warnings with their job ID.
+func TestMicroBatcher_WithStructuredLogger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var buf bytes.Buffer
+	mb := embat.NewMicroBatcher[string, int](
+		mock.NewMockBatchProcessor[string, int](ctrl),
+		embat.WithStructuredLogger[string, int](embat.NewSlogLogger(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+		}))),
+	)
+	mb.Shutdown()
+
+	job := embat.NewJob("test-job")
+	<-mb.Submit(job)
+	assert.Contains(t, buf.String(), "level=WARN msg=\"job submitted after shutdown\" job_id="+string(job.ID))
+}
+
+// TestMicroBatcher_WithMetrics tests that submissions, batches and results are measured.
+func TestMicroBatcher_WithMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Len(2)).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			return []embat.Result[int]{
+				embat.NewResult(jobs[0].ID, 42, nil),
+				embat.NewResult(jobs[1].ID, 0, errors.New("failed")),
+			}
+		}).Times(1)
+
+	m := embat.NewMemoryMetrics()
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithMetrics[string, int](m),
+	)
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("test-job-1")),
+		mb.Submit(embat.NewJob("test-job-2")),
+	}
+	for _, resultCh := range resultChs {
+		<-resultCh
+	}
+	mb.Shutdown()
+	<-mb.Submit(embat.NewJob("too-late"))
+
+	s := m.Snapshot()
+	assert.Equal(t, uint64(2), s.Submitted)
+	assert.Equal(t, uint64(1), s.Rejected)
+	assert.Equal(t, uint64(2), s.Completed)
+	assert.Equal(t, uint64(1), s.Failed)
+	assert.Equal(t, uint64(1), s.Batches)
+	assert.Equal(t, 2.0, s.BatchSize.Sum)
+	assert.Equal(t, uint64(1), s.ProcessDuration.Count)
+	assert.Equal(t, uint64(2), s.Latency.Count)
+	assert.Equal(t, 0, s.QueueDepth)
+}
+
+// TestMicroBatcher_WithMetrics_withdrawn tests that a job whose context is cancelled while waiting for room
+// in the queue is counted once, as completed if it was withdrawn first or as rejected otherwise.
+func TestMicroBatcher_WithMetrics_withdrawn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			<-release
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	m := embat.NewMemoryMetrics()
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithMetrics[string, int](m),
+	)
+	mb.Submit(embat.NewJob("in-flight"))
+	time.Sleep(50 * time.Millisecond)
+	mb.Submit(embat.NewJob("queued"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultCh := make(chan (<-chan embat.Result[int]), 1)
+	go func() { resultCh <- mb.SubmitContext(ctx, embat.NewJob("withdrawn")) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, (<-<-resultCh).Err, context.Canceled)
+	close(release)
+	mb.Shutdown()
+
+	snapshot := m.Snapshot()
+	assert.Equal(t, uint64(3), snapshot.Completed+snapshot.Rejected)
+	assert.Equal(t, uint64(1), snapshot.Failed+snapshot.Rejected)
+	s := mb.Stats()
+	assert.Equal(t, uint64(3), s.Processed+s.Rejected)
+	assert.Equal(t, uint64(1), s.Failed+s.Rejected)
+}
+
+// TestMicroBatcher_WithTracer tests that job spans are children of the caller's span, linked from the batch span
+// the batch is processed with, and record the life of the job.
+func TestMicroBatcher_WithTracer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tr := embat.NewMemoryTracer()
+	processed := make(chan embat.SpanID, 1)
+	mbp := mock.NewMockBatchProcessorContext[string, int](ctrl)
+	mbp.EXPECT().
+		ProcessContext(gomock.Any(), gomock.Len(2)).
+		DoAndReturn(func(ctx context.Context, jobs []embat.Job[string]) []embat.Result[int] {
+			processed <- embat.SpanFromContext(ctx)
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, 42, nil))
+			}
+			return results
+		}).Times(1)
+
+	mb := embat.NewMicroBatcherContext[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithTracer[string, int](tr),
+	)
+	ctx, request := tr.Start(context.Background(), "request")
+	resultChs := []<-chan embat.Result[int]{
+		mb.SubmitContext(ctx, embat.NewJob("test-job-1")),
+		mb.SubmitContext(ctx, embat.NewJob("test-job-2")),
+	}
+	for _, resultCh := range resultChs {
+		assert.NoError(t, (<-resultCh).Err)
+	}
+	request.End(nil)
+	mb.Shutdown()
+
+	spans := tr.Spans()
+	require.Len(t, spans, 4)
+	batch := spans[3]
+	assert.Equal(t, "batch", batch.Name)
+	assert.Equal(t, batch.ID, <-processed)
+	assert.Equal(t, []embat.SpanID{spans[1].ID, spans[2].ID}, batch.Links)
+	assert.True(t, batch.Ended)
+	assert.NoError(t, batch.Err)
+	for _, job := range spans[1:3] {
+		assert.Equal(t, "job", job.Name)
+		assert.Equal(t, spans[0].ID, job.Parent)
+		assert.True(t, job.Ended)
+		var events []string
+		for _, e := range job.Events {
+			events = append(events, e.Name)
+		}
+		assert.Equal(t, []string{embat.EventEnqueued, embat.EventDispatched, embat.EventCompleted}, events)
+		assert.Equal(t, []any{embat.KeyBatchID, batch.BatchID, embat.KeyAttempt, 1}, job.Events[1].Attrs)
+	}
+}
+
+// TestMicroBatcher_WithTracer_rejected tests that the span of a job rejected by a full queue records no enqueued event.
+func TestMicroBatcher_WithTracer_rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			<-release
+			return []embat.Result[int]{embat.NewResult(jobs[0].ID, 42, nil)}
+		}).Times(2)
+
+	tr := embat.NewMemoryTracer()
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](1),
+		embat.WithOverflowPolicy[string, int](embat.OverflowFail),
+		embat.WithTracer[string, int](tr),
+	)
+	_, err := mb.TrySubmit(embat.NewJob("in-flight"))
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	_, err = mb.TrySubmit(embat.NewJob("queued"))
+	require.NoError(t, err)
+	_, err = mb.TrySubmit(embat.NewJob("overflow"))
+	assert.ErrorIs(t, err, embat.ErrQueueFull)
+	close(release)
+	mb.Shutdown()
+
+	var rejected []embat.RecordedSpan
+	for _, span := range tr.Spans() {
+		if span.Name == "job" && errors.Is(span.Err, embat.ErrQueueFull) {
+			rejected = append(rejected, span)
+		}
+	}
+	require.Len(t, rejected, 1)
+	assert.True(t, rejected[0].Ended)
+	assert.Empty(t, rejected[0].Events)
+}
+
+// TestMicroBatcher_Stats tests that Stats reports the queued, in-flight and pending jobs of a running batcher
+// and the totals once it has stopped.
+func TestMicroBatcher_Stats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mbp := mock.NewMockBatchProcessor[string, int](ctrl)
+	mbp.EXPECT().
+		Process(gomock.Any()).
+		DoAndReturn(func(jobs []embat.Job[string]) []embat.Result[int] {
+			if jobs[0].Data == "test-job-1" {
+				close(started)
+				<-release
+			}
+			var results []embat.Result[int]
+			for _, job := range jobs {
+				results = append(results, embat.NewResult(job.ID, 42, nil))
+			}
+			results[len(results)-1].Err = errors.New("failed")
+			return results
+		}).Times(2)
+
+	mb := embat.NewMicroBatcher[string, int](
+		mbp,
+		embat.WithFrequency[string, int](time.Hour),
+		embat.WithBatchSize[string, int](2),
+		embat.WithQueueCapacity[string, int](4),
+	)
+	s := mb.Stats()
+	assert.Equal(t, embat.Stats{}, s)
+
+	before := time.Now()
+	resultChs := []<-chan embat.Result[int]{
+		mb.Submit(embat.NewJob("test-job-1")),
+		mb.Submit(embat.NewJob("test-job-2")),
+	}
+	<-started
+	resultChs = append(resultChs, mb.Submit(embat.NewJob("test-job-3")))
+
+	s = mb.Stats()
+	assert.Equal(t, 1, s.QueuedJobs)
+	assert.Equal(t, 1, s.InFlightBatches)
+	assert.Equal(t, 3, s.PendingResults)
+	assert.Equal(t, uint64(0), s.Processed)
+	assert.False(t, s.LastBatch.Before(before))
+	assert.False(t, s.ShuttingDown)
+
+	close(release)
+	mb.Shutdown()
+	for _, resultCh := range resultChs {
+		<-resultCh
+	}
+	<-mb.Submit(embat.NewJob("too-late"))
+
+	s = mb.Stats()
+	assert.Equal(t, 0, s.QueuedJobs)
+	assert.Equal(t, 0, s.InFlightBatches)
+	assert.Equal(t, 0, s.PendingResults)
+	assert.Equal(t, uint64(3), s.Processed)
+	assert.Equal(t, uint64(2), s.Failed)
+	assert.Equal(t, uint64(1), s.Rejected)
+	assert.True(t, s.ShuttingDown)
+	assert.True(t, s.Stopped)
+}
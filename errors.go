@@ -0,0 +1,68 @@
+package embat
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrShutdown is returned for jobs submitted after shutdown has been initiated.
+	ErrShutdown = errors.New("job submitted after shutdown")
+	// ErrQueueFull is returned for jobs rejected because the queue had no room.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrDropped is returned for queued jobs dropped to make room for newer ones with OverflowDropOldest.
+	ErrDropped = errors.New("job dropped from full queue")
+	// ErrOversize is returned for jobs heavier than the maximum batch weight with OversizeReject.
+	ErrOversize = errors.New("job exceeds maximum batch weight")
+	// ErrNoResult is returned for jobs the processor returned no result for.
+	ErrNoResult = errors.New("processor returned no result for job")
+	// ErrUnknownJob is reported for results whose job ID is not part of the processed batch.
+	ErrUnknownJob = errors.New("result for job not in batch")
+	// ErrDuplicateResult is reported for results repeating the job ID of an earlier result in the same batch.
+	ErrDuplicateResult = errors.New("duplicate result for job")
+	// ErrProcessTimeout is returned for jobs of a batch the processor did not finish within the process timeout.
+	ErrProcessTimeout = errors.New("batch processing timed out")
+	// ErrInvalidBatchSize is returned when setting a batch size below one.
+	ErrInvalidBatchSize = errors.New("batch size must be at least one")
+	// ErrBatchSizeExceedsCapacity is returned when setting a batch size the queue cannot hold.
+	ErrBatchSizeExceedsCapacity = errors.New("batch size exceeds queue capacity")
+	// ErrInvalidFrequency is returned when setting a frequency that is not positive.
+	ErrInvalidFrequency = errors.New("frequency must be positive")
+	// ErrShutdownAborted is returned for jobs that were still pending when a ShutdownContext call gave up.
+	ErrShutdownAborted = errors.New("shutdown aborted before job was processed")
+)
+
+// PanicError is returned for every job of a batch whose processor panicked.
+type PanicError struct {
+	// Value is the value the processor panicked with.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error returns the panic value as an error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("processor panicked: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// BatchError is returned for every job of a batch the processor failed as a whole.
+type BatchError struct {
+	// Err is the error returned by the processor.
+	Err error
+}
+
+// Error returns the processor's error message.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("processor failed batch: %v", e.Err)
+}
+
+// Unwrap returns the processor's error.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -1,17 +1,36 @@
 package embat
 
+import (
+	"context"
+)
+
 // jobsC holds a chan of jobs to be processed.
 type jobsC[J any] struct {
 	c chan Job[J]
 }
 
-// add safely adds a job to the chan.
-func (j jobsC[J]) add(job Job[J]) {
-	j.c <- job
+// Add safely adds a job to the chan, waiting for room until ctx is done.
+func (j jobsC[J]) Add(ctx context.Context, job Job[J]) error {
+	select {
+	case j.c <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAdd adds a job to the chan if it has room and reports whether it did.
+func (j jobsC[J]) TryAdd(job Job[J]) bool {
+	select {
+	case j.c <- job:
+		return true
+	default:
+		return false
+	}
 }
 
-// next returns the next batch of jobs to be processed and removes them from the jobs chan.
-func (j jobsC[J]) next(defaultBatchSize int) []Job[J] {
+// Next returns the next batch of jobs to be processed and removes them from the jobs chan.
+func (j jobsC[J]) Next(defaultBatchSize int) []Job[J] {
 	jLength := len(j.c)
 	// If there are no jobs, return an empty slice.
 	if jLength == 0 {
@@ -23,20 +42,26 @@ func (j jobsC[J]) next(defaultBatchSize int) []Job[J] {
 	if jLength < defaultBatchSize {
 		batchSize = jLength
 	}
-	batch := make([]Job[J], batchSize)
-	// Copy the jobs to be processed the batch.
-	for i := range batch {
-		batch[i] = <-j.c
+	batch := make([]Job[J], 0, batchSize)
+	// Copy the jobs to be processed the batch, without blocking in case another caller took them first.
+	for len(batch) < batchSize {
+		select {
+		case job := <-j.c:
+			batch = append(batch, job)
+		default:
+			return batch
+		}
 	}
 
 	return batch
 }
 
-// length returns the number of jobs in the jobs chan.
-func (j jobsC[J]) length() int {
+// Len returns the number of jobs in the jobs chan.
+func (j jobsC[J]) Len() int {
 	return len(j.c)
 }
 
-func (j jobsC[J]) close() {
+// Close closes the jobs chan.
+func (j jobsC[J]) Close() {
 	close(j.c)
 }
@@ -1,8 +1,10 @@
 package embat
 
 import (
+	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -18,13 +20,13 @@ func Test_jobsC_add(t *testing.T) {
 	for i := 0; i < numJobs; i++ {
 		go func(id int) {
 			defer wg.Done()
-			j.add(Job[int]{ID: JobID(rune(id)), Data: id})
+			_ = j.Add(context.Background(), Job[int]{ID: JobID(rune(id)), Data: id})
 		}(i)
 	}
 	wg.Wait()
 
 	// Check length of jobs.s
-	if j.length() != numJobs {
+	if j.Len() != numJobs {
 		t.Errorf("Expected %d jobs, got %d", numJobs, len(j.c))
 	}
 }
@@ -55,7 +57,7 @@ func Test_jobsC_next(t *testing.T) {
 				j := jobsC[int]{
 					c: make(chan Job[int], 3),
 				}
-				j.add(Job[int]{ID: JobID('a'), Data: 1})
+				_ = j.Add(context.Background(), Job[int]{ID: JobID('a'), Data: 1})
 				return j
 			}(),
 			nextBatch: []Job[int]{
@@ -70,7 +72,7 @@ func Test_jobsC_next(t *testing.T) {
 				j := jobsC[int]{
 					c: make(chan Job[int], 1),
 				}
-				j.add(Job[int]{ID: JobID('a'), Data: 1})
+				_ = j.Add(context.Background(), Job[int]{ID: JobID('a'), Data: 1})
 				return j
 			}(),
 			nextBatch: []Job[int]{
@@ -85,8 +87,8 @@ func Test_jobsC_next(t *testing.T) {
 				j := jobsC[int]{
 					c: make(chan Job[int], 2),
 				}
-				j.add(Job[int]{ID: JobID('a'), Data: 1})
-				j.add(Job[int]{ID: JobID('b'), Data: 2})
+				_ = j.Add(context.Background(), Job[int]{ID: JobID('a'), Data: 1})
+				_ = j.Add(context.Background(), Job[int]{ID: JobID('b'), Data: 2})
 				return j
 			}(),
 			nextBatch: []Job[int]{
@@ -97,8 +99,27 @@ func Test_jobsC_next(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.nextBatch, tt.j.next(tt.defaultBatchSize), "next(%v)", tt.defaultBatchSize)
+			assert.Equalf(t, tt.nextBatch, tt.j.Next(tt.defaultBatchSize), "next(%v)", tt.defaultBatchSize)
 			assert.Equalf(t, tt.numberOfRemainingJobs, len(tt.j.c), "next(%v)", tt.defaultBatchSize)
 		})
 	}
 }
+
+// Test_jobsC_tryAdd tests that jobs are only added while the chan has room.
+func Test_jobsC_tryAdd(t *testing.T) {
+	j := jobsC[int]{c: make(chan Job[int], 1)}
+	assert.True(t, j.TryAdd(Job[int]{ID: JobID('a'), Data: 1}))
+	assert.False(t, j.TryAdd(Job[int]{ID: JobID('b'), Data: 2}))
+	assert.Equal(t, 1, j.Len())
+}
+
+// Test_jobsC_addContext tests that adding to a full chan gives up when the context is done.
+func Test_jobsC_addContext(t *testing.T) {
+	j := jobsC[int]{c: make(chan Job[int], 1)}
+	assert.NoError(t, j.Add(context.Background(), Job[int]{ID: JobID('a'), Data: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, j.Add(ctx, Job[int]{ID: JobID('b'), Data: 2}), context.DeadlineExceeded)
+	assert.Equal(t, 1, j.Len())
+}
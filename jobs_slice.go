@@ -1,6 +1,7 @@
 package embat
 
 import (
+	"context"
 	"sync"
 )
 
@@ -10,15 +11,22 @@ type jobsS[J any] struct {
 	s  []Job[J]
 }
 
-// add safely adds a job to the jobs slice.
-func (j *jobsS[J]) add(job Job[J]) {
+// Add safely adds a job to the jobs slice, it never waits.
+func (j *jobsS[J]) Add(_ context.Context, job Job[J]) error {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 	j.s = append(j.s, job)
+	return nil
 }
 
-// next returns the next batch of jobs to be processed and removes them from the jobs slice.
-func (j *jobsS[J]) next(defaultBatchSize int) []Job[J] {
+// TryAdd adds a job to the jobs slice, it always has room.
+func (j *jobsS[J]) TryAdd(job Job[J]) bool {
+	_ = j.Add(context.Background(), job)
+	return true
+}
+
+// Next returns the next batch of jobs to be processed and removes them from the jobs slice.
+func (j *jobsS[J]) Next(defaultBatchSize int) []Job[J] {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
@@ -41,14 +49,14 @@ func (j *jobsS[J]) next(defaultBatchSize int) []Job[J] {
 	return batch
 }
 
-// length returns the number of jobs in the jobs slice.
-func (j *jobsS[J]) length() int {
+// Len returns the number of jobs in the jobs slice.
+func (j *jobsS[J]) Len() int {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 	return len(j.s)
 }
 
-// close fulfills the interface but does nothing for this implementation.
-func (j *jobsS[J]) close() {
+// Close fulfills the interface but does nothing for this implementation.
+func (j *jobsS[J]) Close() {
 	return
 }
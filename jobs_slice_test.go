@@ -1,6 +1,7 @@
 package embat
 
 import (
+	"context"
 	"sync"
 	"testing"
 
@@ -18,7 +19,7 @@ func Test_jobs_add(t *testing.T) {
 	for i := 0; i < numJobs; i++ {
 		go func(id int) {
 			defer wg.Done()
-			j.add(Job[int]{ID: JobID(rune(id)), Data: id})
+			_ = j.Add(context.Background(), Job[int]{ID: JobID(rune(id)), Data: id})
 		}(i)
 	}
 	wg.Wait()
@@ -101,7 +102,7 @@ func Test_jobs_next(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.nextBatch, tt.j.next(tt.defaultBatchSize), "next(%v)", tt.defaultBatchSize)
+			assert.Equalf(t, tt.nextBatch, tt.j.Next(tt.defaultBatchSize), "next(%v)", tt.defaultBatchSize)
 			assert.Equalf(t, tt.remainingJobs, tt.j.s, "next(%v)", tt.defaultBatchSize)
 		})
 	}
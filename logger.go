@@ -1,14 +1,107 @@
 //go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
 package embat
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Attribute keys of the key-value pairs the MicroBatcher adds to structured log messages.
+const (
+	// KeyJobID is the ID of the job a message is about.
+	KeyJobID = "job_id"
+	// KeyBatchID is the ID of the batch a message is about.
+	KeyBatchID = "batch_id"
+	// KeyBatchSize is the number of jobs in a batch, or the batch size.
+	KeyBatchSize = "batch_size"
+	// KeyDuration is how long something took or will take.
+	KeyDuration = "duration"
+	// KeyAttempt is the number of a processing attempt.
+	KeyAttempt = "attempt"
+	// KeyError is the error a message is about.
+	KeyError = "error"
+)
+
 // Logger prints debug messages.
 type Logger interface {
 	// Debug prints a debug message.
 	Debug(format string, args ...any)
 }
 
+// StructuredLogger prints leveled messages with alternating key-value pairs, *slog.Logger implements it.
+type StructuredLogger interface {
+	// Debug prints a debug message.
+	Debug(msg string, args ...any)
+	// Info prints an informational message.
+	Info(msg string, args ...any)
+	// Warn prints a warning, e.g. a job was rejected.
+	Warn(msg string, args ...any)
+	// Error prints an error, e.g. a batch failed.
+	Error(msg string, args ...any)
+}
+
 // noOpLogger is a logger that does nothing.
 type noOpLogger struct{}
 
 // Debug does nothing.
 func (noOpLogger) Debug(format string, args ...any) {}
+
+// Info does nothing.
+func (noOpLogger) Info(msg string, args ...any) {}
+
+// Warn does nothing.
+func (noOpLogger) Warn(msg string, args ...any) {}
+
+// Error does nothing.
+func (noOpLogger) Error(msg string, args ...any) {}
+
+// debugLogger adapts a Logger to a StructuredLogger, messages of every level are printed as debug messages
+// prefixed with their level and followed by their key-value pairs.
+type debugLogger struct {
+	logger Logger
+}
+
+// Debug prints a debug message.
+func (l debugLogger) Debug(msg string, args ...any) {
+	l.print("", msg, args)
+}
+
+// Info prints an informational message.
+func (l debugLogger) Info(msg string, args ...any) {
+	l.print("info: ", msg, args)
+}
+
+// Warn prints a warning.
+func (l debugLogger) Warn(msg string, args ...any) {
+	l.print("warn: ", msg, args)
+}
+
+// Error prints an error.
+func (l debugLogger) Error(msg string, args ...any) {
+	l.print("error: ", msg, args)
+}
+
+// print formats the message with its key-value pairs as key=value and prints it with the Logger.
+func (l debugLogger) print(prefix, msg string, args []any) {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	l.logger.Debug("%s", b.String())
+}
+
+// printfLogger adapts a StructuredLogger to a Logger, debug messages are formatted before they are printed.
+type printfLogger struct {
+	logger StructuredLogger
+}
+
+// Debug prints a debug message.
+func (l printfLogger) Debug(format string, args ...any) {
+	l.logger.Debug(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,41 @@
+package embat
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger records every debug message it prints.
+type recordingLogger struct {
+	lines []string
+}
+
+// Debug records the formatted message.
+func (l *recordingLogger) Debug(format string, args ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+// Test_debugLogger tests that structured messages are printed with their level and key-value pairs.
+func Test_debugLogger(t *testing.T) {
+	r := &recordingLogger{}
+	l := debugLogger{logger: r}
+	l.Debug("job submitted", KeyJobID, "1")
+	l.Info("shutdown initiated")
+	l.Warn("job rejected", KeyJobID, "2", KeyError, ErrQueueFull)
+	l.Error("processor failed batch", KeyBatchSize, 3, "dangling")
+	assert.Equal(t, []string{
+		"job submitted job_id=1",
+		"info: shutdown initiated",
+		"warn: job rejected job_id=2 error=job queue is full",
+		"error: processor failed batch batch_size=3 dangling",
+	}, r.lines)
+}
+
+// Test_printfLogger tests that debug messages are formatted before they are printed.
+func Test_printfLogger(t *testing.T) {
+	r := &recordingLogger{}
+	printfLogger{logger: debugLogger{logger: r}}.Debug("batch of %d jobs", 3)
+	assert.Equal(t, []string{"batch of 3 jobs"}, r.lines)
+}
@@ -0,0 +1,205 @@
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+package embat
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the MicroBatcher, this interface can be implemented by the consumer.
+// Its methods are called from submitting and processing goroutines, so implementations must be safe for concurrent use
+// and should return quickly.
+type Metrics interface {
+	// QueueDepth records the number of queued jobs, after jobs are queued and after a batch is taken off the queue.
+	QueueDepth(n int)
+	// JobSubmitted records a job accepted by the MicroBatcher.
+	JobSubmitted()
+	// JobRejected records a job the MicroBatcher did not accept, with the error it was rejected with.
+	JobRejected(err error)
+	// JobRetried records a failed job scheduled for another attempt.
+	JobRetried()
+	// JobCompleted records the result of an accepted job, with the time from its submission to its result.
+	// It is called for every accepted job, also when it fails or is withdrawn.
+	JobCompleted(latency time.Duration, err error)
+	// BatchDispatched records the number of jobs in a batch dispatched to the processor.
+	BatchDispatched(size int)
+	// BatchProcessed records how long the processor took to process a batch of the given size.
+	BatchProcessed(size int, duration time.Duration)
+}
+
+// noOpMetrics is a Metrics that does nothing.
+type noOpMetrics struct{}
+
+// QueueDepth does nothing.
+func (noOpMetrics) QueueDepth(int) {}
+
+// JobSubmitted does nothing.
+func (noOpMetrics) JobSubmitted() {}
+
+// JobRejected does nothing.
+func (noOpMetrics) JobRejected(error) {}
+
+// JobRetried does nothing.
+func (noOpMetrics) JobRetried() {}
+
+// JobCompleted does nothing.
+func (noOpMetrics) JobCompleted(time.Duration, error) {}
+
+// BatchDispatched does nothing.
+func (noOpMetrics) BatchDispatched(int) {}
+
+// BatchProcessed does nothing.
+func (noOpMetrics) BatchProcessed(int, time.Duration) {}
+
+var (
+	// DefaultSizeBuckets are the upper bounds of the batch size histogram of MemoryMetrics.
+	DefaultSizeBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+	// DefaultDurationBuckets are the upper bounds, in seconds, of the duration histograms of MemoryMetrics.
+	DefaultDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+// Histogram is a snapshot of the distribution of observed values.
+type Histogram struct {
+	// Bounds are the inclusive upper bounds of the buckets, in ascending order.
+	Bounds []float64
+	// Counts holds the number of observations in each bucket, the last one counts observations above every bound.
+	Counts []uint64
+	// Count is the number of observations.
+	Count uint64
+	// Sum is the sum of the observed values.
+	Sum float64
+}
+
+// newHistogram returns an empty histogram with the given bucket bounds.
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)}
+}
+
+// observe records a value.
+func (h *Histogram) observe(v float64) {
+	h.Counts[sort.SearchFloat64s(h.Bounds, v)]++
+	h.Count++
+	h.Sum += v
+}
+
+// clone returns a copy of the histogram that does not share its counts.
+func (h *Histogram) clone() Histogram {
+	c := *h
+	c.Counts = append([]uint64(nil), h.Counts...)
+	return c
+}
+
+// MetricsSnapshot holds the measurements recorded by MemoryMetrics.
+type MetricsSnapshot struct {
+	// QueueDepth is the last recorded number of queued jobs.
+	QueueDepth int
+	// Submitted is the number of accepted jobs.
+	Submitted uint64
+	// Rejected is the number of jobs that were not accepted.
+	Rejected uint64
+	// Retried is the number of failed jobs scheduled for another attempt.
+	Retried uint64
+	// Completed is the number of accepted jobs that received their result.
+	Completed uint64
+	// Failed is the number of accepted jobs whose result held an error.
+	Failed uint64
+	// Batches is the number of batches dispatched to the processor.
+	Batches uint64
+	// BatchSize is the distribution of the number of jobs per dispatched batch.
+	BatchSize Histogram
+	// ProcessDuration is the distribution of how long the processor took per batch, in seconds.
+	ProcessDuration Histogram
+	// Latency is the distribution of the time from submitting a job to its result, in seconds.
+	Latency Histogram
+}
+
+// MemoryMetrics is a Metrics that keeps counters and histograms in memory, e.g. to assert on in tests
+// or to export with PublishExpvar.
+type MemoryMetrics struct {
+	mu sync.Mutex
+	s  MetricsSnapshot
+}
+
+// NewMemoryMetrics creates a MemoryMetrics with the default histogram buckets.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{s: MetricsSnapshot{
+		BatchSize:       newHistogram(DefaultSizeBuckets),
+		ProcessDuration: newHistogram(DefaultDurationBuckets),
+		Latency:         newHistogram(DefaultDurationBuckets),
+	}}
+}
+
+// QueueDepth records the number of queued jobs.
+func (m *MemoryMetrics) QueueDepth(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.QueueDepth = n
+}
+
+// JobSubmitted counts an accepted job.
+func (m *MemoryMetrics) JobSubmitted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.Submitted++
+}
+
+// JobRejected counts a rejected job.
+func (m *MemoryMetrics) JobRejected(error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.Rejected++
+}
+
+// JobRetried counts a retried job.
+func (m *MemoryMetrics) JobRetried() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.Retried++
+}
+
+// JobCompleted counts a completed job, and a failed one if err is not nil, and records its latency.
+func (m *MemoryMetrics) JobCompleted(latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.Completed++
+	if err != nil {
+		m.s.Failed++
+	}
+	m.s.Latency.observe(latency.Seconds())
+}
+
+// BatchDispatched counts a dispatched batch and records its size.
+func (m *MemoryMetrics) BatchDispatched(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.Batches++
+	m.s.BatchSize.observe(float64(size))
+}
+
+// BatchProcessed records how long the processor took.
+func (m *MemoryMetrics) BatchProcessed(_ int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.s.ProcessDuration.observe(duration.Seconds())
+}
+
+// Snapshot returns a copy of the measurements recorded so far.
+func (m *MemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.s
+	s.BatchSize = m.s.BatchSize.clone()
+	s.ProcessDuration = m.s.ProcessDuration.clone()
+	s.Latency = m.s.Latency.clone()
+	return s
+}
+
+// PublishExpvar exports the snapshot of the metrics as an expvar variable with the given name,
+// served as JSON under /debug/vars. Like expvar.Publish it panics if the name is already in use.
+func PublishExpvar(name string, m *MemoryMetrics) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return m.Snapshot()
+	}))
+}
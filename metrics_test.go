@@ -0,0 +1,66 @@
+package embat_test
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nayanbhana/embat"
+)
+
+// TestMemoryMetrics tests that the counters and histograms record the measurements.
+func TestMemoryMetrics(t *testing.T) {
+	m := embat.NewMemoryMetrics()
+	m.QueueDepth(3)
+	m.JobSubmitted()
+	m.JobSubmitted()
+	m.JobRejected(embat.ErrQueueFull)
+	m.JobRetried()
+	m.JobCompleted(20*time.Millisecond, nil)
+	m.JobCompleted(2*time.Second, errors.New("failed"))
+	m.BatchDispatched(2)
+	m.BatchProcessed(2, 30*time.Millisecond)
+
+	s := m.Snapshot()
+	assert.Equal(t, 3, s.QueueDepth)
+	assert.Equal(t, uint64(2), s.Submitted)
+	assert.Equal(t, uint64(1), s.Rejected)
+	assert.Equal(t, uint64(1), s.Retried)
+	assert.Equal(t, uint64(2), s.Completed)
+	assert.Equal(t, uint64(1), s.Failed)
+	assert.Equal(t, uint64(1), s.Batches)
+
+	assert.Equal(t, uint64(1), s.BatchSize.Count)
+	assert.Equal(t, 2.0, s.BatchSize.Sum)
+	// A value equal to a bound falls into that bound's bucket.
+	assert.Equal(t, uint64(1), s.BatchSize.Counts[1])
+	assert.Equal(t, uint64(1), s.ProcessDuration.Counts[4])
+	assert.Equal(t, uint64(1), s.Latency.Counts[3])
+	assert.Equal(t, uint64(1), s.Latency.Counts[9])
+	assert.Len(t, s.Latency.Counts, len(s.Latency.Bounds)+1)
+
+	// The snapshot does not change with later measurements.
+	m.BatchDispatched(5000)
+	assert.Equal(t, uint64(0), s.BatchSize.Counts[len(s.BatchSize.Counts)-1])
+	assert.Equal(t, uint64(1), m.Snapshot().BatchSize.Counts[len(s.BatchSize.Counts)-1])
+}
+
+// TestPublishExpvar tests that the metrics are exported as JSON.
+func TestPublishExpvar(t *testing.T) {
+	m := embat.NewMemoryMetrics()
+	m.JobSubmitted()
+	// expvar names are global, so every run publishes under a new name.
+	name := "embat_test_metrics_" + string(embat.NewJobID())
+	embat.PublishExpvar(name, m)
+
+	v := expvar.Get(name)
+	require.NotNil(t, v)
+	var s embat.MetricsSnapshot
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &s))
+	assert.Equal(t, uint64(1), s.Submitted)
+}
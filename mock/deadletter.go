@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: deadletter.go
+//
+// Generated by this command:
+//
+//	mockgen -source=deadletter.go -destination=./mock/deadletter.go -package=mock
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	embat "github.com/nayanbhana/embat"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDeadLetter is a mock of DeadLetter interface.
+type MockDeadLetter[J any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadLetterMockRecorder[J]
+}
+
+// MockDeadLetterMockRecorder is the mock recorder for MockDeadLetter.
+type MockDeadLetterMockRecorder[J any] struct {
+	mock *MockDeadLetter[J]
+}
+
+// NewMockDeadLetter creates a new mock instance.
+func NewMockDeadLetter[J any](ctrl *gomock.Controller) *MockDeadLetter[J] {
+	mock := &MockDeadLetter[J]{ctrl: ctrl}
+	mock.recorder = &MockDeadLetterMockRecorder[J]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLetter[J]) EXPECT() *MockDeadLetterMockRecorder[J] {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockDeadLetter[J]) Send(entry embat.DeadLetterEntry[J]) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockDeadLetterMockRecorder[J]) Send(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockDeadLetter[J])(nil).Send), entry)
+}
@@ -10,6 +10,7 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
 	embat "github.com/nayanbhana/embat"
@@ -52,3 +53,78 @@ func (mr *MockBatchProcessorMockRecorder[J, R]) Process(batch any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockBatchProcessor[J, R])(nil).Process), batch)
 }
+
+// MockBatchProcessorContext is a mock of BatchProcessorContext interface.
+type MockBatchProcessorContext[J any, R any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchProcessorContextMockRecorder[J, R]
+}
+
+// MockBatchProcessorContextMockRecorder is the mock recorder for MockBatchProcessorContext.
+type MockBatchProcessorContextMockRecorder[J any, R any] struct {
+	mock *MockBatchProcessorContext[J, R]
+}
+
+// NewMockBatchProcessorContext creates a new mock instance.
+func NewMockBatchProcessorContext[J any, R any](ctrl *gomock.Controller) *MockBatchProcessorContext[J, R] {
+	mock := &MockBatchProcessorContext[J, R]{ctrl: ctrl}
+	mock.recorder = &MockBatchProcessorContextMockRecorder[J, R]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchProcessorContext[J, R]) EXPECT() *MockBatchProcessorContextMockRecorder[J, R] {
+	return m.recorder
+}
+
+// ProcessContext mocks base method.
+func (m *MockBatchProcessorContext[J, R]) ProcessContext(ctx context.Context, batch []embat.Job[J]) []embat.Result[R] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessContext", ctx, batch)
+	ret0, _ := ret[0].([]embat.Result[R])
+	return ret0
+}
+
+// ProcessContext indicates an expected call of ProcessContext.
+func (mr *MockBatchProcessorContextMockRecorder[J, R]) ProcessContext(ctx, batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessContext", reflect.TypeOf((*MockBatchProcessorContext[J, R])(nil).ProcessContext), ctx, batch)
+}
+
+// MockFallibleBatchProcessor is a mock of FallibleBatchProcessor interface.
+type MockFallibleBatchProcessor[J any, R any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockFallibleBatchProcessorMockRecorder[J, R]
+}
+
+// MockFallibleBatchProcessorMockRecorder is the mock recorder for MockFallibleBatchProcessor.
+type MockFallibleBatchProcessorMockRecorder[J any, R any] struct {
+	mock *MockFallibleBatchProcessor[J, R]
+}
+
+// NewMockFallibleBatchProcessor creates a new mock instance.
+func NewMockFallibleBatchProcessor[J any, R any](ctrl *gomock.Controller) *MockFallibleBatchProcessor[J, R] {
+	mock := &MockFallibleBatchProcessor[J, R]{ctrl: ctrl}
+	mock.recorder = &MockFallibleBatchProcessorMockRecorder[J, R]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFallibleBatchProcessor[J, R]) EXPECT() *MockFallibleBatchProcessorMockRecorder[J, R] {
+	return m.recorder
+}
+
+// ProcessBatch mocks base method.
+func (m *MockFallibleBatchProcessor[J, R]) ProcessBatch(ctx context.Context, batch []embat.Job[J]) ([]embat.Result[R], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessBatch", ctx, batch)
+	ret0, _ := ret[0].([]embat.Result[R])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessBatch indicates an expected call of ProcessBatch.
+func (mr *MockFallibleBatchProcessorMockRecorder[J, R]) ProcessBatch(ctx, batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBatch", reflect.TypeOf((*MockFallibleBatchProcessor[J, R])(nil).ProcessBatch), ctx, batch)
+}
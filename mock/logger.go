@@ -54,3 +54,94 @@ func (mr *MockLoggerMockRecorder) Debug(format any, args ...any) *gomock.Call {
 	varargs := append([]any{format}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockLogger)(nil).Debug), varargs...)
 }
+
+// MockStructuredLogger is a mock of StructuredLogger interface.
+type MockStructuredLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockStructuredLoggerMockRecorder
+}
+
+// MockStructuredLoggerMockRecorder is the mock recorder for MockStructuredLogger.
+type MockStructuredLoggerMockRecorder struct {
+	mock *MockStructuredLogger
+}
+
+// NewMockStructuredLogger creates a new mock instance.
+func NewMockStructuredLogger(ctrl *gomock.Controller) *MockStructuredLogger {
+	mock := &MockStructuredLogger{ctrl: ctrl}
+	mock.recorder = &MockStructuredLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStructuredLogger) EXPECT() *MockStructuredLoggerMockRecorder {
+	return m.recorder
+}
+
+// Debug mocks base method.
+func (m *MockStructuredLogger) Debug(msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Debug", varargs...)
+}
+
+// Debug indicates an expected call of Debug.
+func (mr *MockStructuredLoggerMockRecorder) Debug(msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockStructuredLogger)(nil).Debug), varargs...)
+}
+
+// Error mocks base method.
+func (m *MockStructuredLogger) Error(msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Error", varargs...)
+}
+
+// Error indicates an expected call of Error.
+func (mr *MockStructuredLoggerMockRecorder) Error(msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockStructuredLogger)(nil).Error), varargs...)
+}
+
+// Info mocks base method.
+func (m *MockStructuredLogger) Info(msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Info", varargs...)
+}
+
+// Info indicates an expected call of Info.
+func (mr *MockStructuredLoggerMockRecorder) Info(msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockStructuredLogger)(nil).Info), varargs...)
+}
+
+// Warn mocks base method.
+func (m *MockStructuredLogger) Warn(msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Warn", varargs...)
+}
+
+// Warn indicates an expected call of Warn.
+func (mr *MockStructuredLoggerMockRecorder) Warn(msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warn", reflect.TypeOf((*MockStructuredLogger)(nil).Warn), varargs...)
+}
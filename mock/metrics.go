@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: metrics.go
+//
+// Generated by this command:
+//
+//	mockgen -source=metrics.go -destination=./mock/metrics.go -package=mock
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// BatchDispatched mocks base method.
+func (m *MockMetrics) BatchDispatched(size int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BatchDispatched", size)
+}
+
+// BatchDispatched indicates an expected call of BatchDispatched.
+func (mr *MockMetricsMockRecorder) BatchDispatched(size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDispatched", reflect.TypeOf((*MockMetrics)(nil).BatchDispatched), size)
+}
+
+// BatchProcessed mocks base method.
+func (m *MockMetrics) BatchProcessed(size int, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BatchProcessed", size, duration)
+}
+
+// BatchProcessed indicates an expected call of BatchProcessed.
+func (mr *MockMetricsMockRecorder) BatchProcessed(size, duration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchProcessed", reflect.TypeOf((*MockMetrics)(nil).BatchProcessed), size, duration)
+}
+
+// JobCompleted mocks base method.
+func (m *MockMetrics) JobCompleted(latency time.Duration, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobCompleted", latency, err)
+}
+
+// JobCompleted indicates an expected call of JobCompleted.
+func (mr *MockMetricsMockRecorder) JobCompleted(latency, err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobCompleted", reflect.TypeOf((*MockMetrics)(nil).JobCompleted), latency, err)
+}
+
+// JobRejected mocks base method.
+func (m *MockMetrics) JobRejected(err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobRejected", err)
+}
+
+// JobRejected indicates an expected call of JobRejected.
+func (mr *MockMetricsMockRecorder) JobRejected(err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobRejected", reflect.TypeOf((*MockMetrics)(nil).JobRejected), err)
+}
+
+// JobRetried mocks base method.
+func (m *MockMetrics) JobRetried() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobRetried")
+}
+
+// JobRetried indicates an expected call of JobRetried.
+func (mr *MockMetricsMockRecorder) JobRetried() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobRetried", reflect.TypeOf((*MockMetrics)(nil).JobRetried))
+}
+
+// JobSubmitted mocks base method.
+func (m *MockMetrics) JobSubmitted() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobSubmitted")
+}
+
+// JobSubmitted indicates an expected call of JobSubmitted.
+func (mr *MockMetricsMockRecorder) JobSubmitted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobSubmitted", reflect.TypeOf((*MockMetrics)(nil).JobSubmitted))
+}
+
+// QueueDepth mocks base method.
+func (m *MockMetrics) QueueDepth(n int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QueueDepth", n)
+}
+
+// QueueDepth indicates an expected call of QueueDepth.
+func (mr *MockMetricsMockRecorder) QueueDepth(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueDepth", reflect.TypeOf((*MockMetrics)(nil).QueueDepth), n)
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: queue.go
+//
+// Generated by this command:
+//
+//	mockgen -source=queue.go -destination=./mock/queue.go -package=mock
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	embat "github.com/nayanbhana/embat"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQueue is a mock of Queue interface.
+type MockQueue[J any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueueMockRecorder[J]
+}
+
+// MockQueueMockRecorder is the mock recorder for MockQueue.
+type MockQueueMockRecorder[J any] struct {
+	mock *MockQueue[J]
+}
+
+// NewMockQueue creates a new mock instance.
+func NewMockQueue[J any](ctrl *gomock.Controller) *MockQueue[J] {
+	mock := &MockQueue[J]{ctrl: ctrl}
+	mock.recorder = &MockQueueMockRecorder[J]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQueue[J]) EXPECT() *MockQueueMockRecorder[J] {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockQueue[J]) Add(ctx context.Context, job embat.Job[J]) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockQueueMockRecorder[J]) Add(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockQueue[J])(nil).Add), ctx, job)
+}
+
+// Close mocks base method.
+func (m *MockQueue[J]) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockQueueMockRecorder[J]) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockQueue[J])(nil).Close))
+}
+
+// Len mocks base method.
+func (m *MockQueue[J]) Len() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockQueueMockRecorder[J]) Len() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockQueue[J])(nil).Len))
+}
+
+// Next mocks base method.
+func (m *MockQueue[J]) Next(max int) []embat.Job[J] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next", max)
+	ret0, _ := ret[0].([]embat.Job[J])
+	return ret0
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockQueueMockRecorder[J]) Next(max any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockQueue[J])(nil).Next), max)
+}
+
+// TryAdd mocks base method.
+func (m *MockQueue[J]) TryAdd(job embat.Job[J]) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryAdd", job)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// TryAdd indicates an expected call of TryAdd.
+func (mr *MockQueueMockRecorder[J]) TryAdd(job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryAdd", reflect.TypeOf((*MockQueue[J])(nil).TryAdd), job)
+}
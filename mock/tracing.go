@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: tracing.go
+//
+// Generated by this command:
+//
+//	mockgen -source=tracing.go -destination=./mock/tracing.go -package=mock
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	embat "github.com/nayanbhana/embat"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTracer is a mock of Tracer interface.
+type MockTracer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTracerMockRecorder
+}
+
+// MockTracerMockRecorder is the mock recorder for MockTracer.
+type MockTracerMockRecorder struct {
+	mock *MockTracer
+}
+
+// NewMockTracer creates a new mock instance.
+func NewMockTracer(ctrl *gomock.Controller) *MockTracer {
+	mock := &MockTracer{ctrl: ctrl}
+	mock.recorder = &MockTracerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTracer) EXPECT() *MockTracerMockRecorder {
+	return m.recorder
+}
+
+// StartBatch mocks base method.
+func (m *MockTracer) StartBatch(ctx context.Context, batchID string, links []embat.Span) (context.Context, embat.Span) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartBatch", ctx, batchID, links)
+	ret0, _ := ret[0].(context.Context)
+	ret1, _ := ret[1].(embat.Span)
+	return ret0, ret1
+}
+
+// StartBatch indicates an expected call of StartBatch.
+func (mr *MockTracerMockRecorder) StartBatch(ctx, batchID, links any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartBatch", reflect.TypeOf((*MockTracer)(nil).StartBatch), ctx, batchID, links)
+}
+
+// StartJob mocks base method.
+func (m *MockTracer) StartJob(ctx context.Context, jobID embat.JobID) embat.Span {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartJob", ctx, jobID)
+	ret0, _ := ret[0].(embat.Span)
+	return ret0
+}
+
+// StartJob indicates an expected call of StartJob.
+func (mr *MockTracerMockRecorder) StartJob(ctx, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartJob", reflect.TypeOf((*MockTracer)(nil).StartJob), ctx, jobID)
+}
+
+// MockSpan is a mock of Span interface.
+type MockSpan struct {
+	ctrl     *gomock.Controller
+	recorder *MockSpanMockRecorder
+}
+
+// MockSpanMockRecorder is the mock recorder for MockSpan.
+type MockSpanMockRecorder struct {
+	mock *MockSpan
+}
+
+// NewMockSpan creates a new mock instance.
+func NewMockSpan(ctrl *gomock.Controller) *MockSpan {
+	mock := &MockSpan{ctrl: ctrl}
+	mock.recorder = &MockSpanMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSpan) EXPECT() *MockSpanMockRecorder {
+	return m.recorder
+}
+
+// AddEvent mocks base method.
+func (m *MockSpan) AddEvent(name string, attrs ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{name}
+	for _, a := range attrs {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "AddEvent", varargs...)
+}
+
+// AddEvent indicates an expected call of AddEvent.
+func (mr *MockSpanMockRecorder) AddEvent(name any, attrs ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name}, attrs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvent", reflect.TypeOf((*MockSpan)(nil).AddEvent), varargs...)
+}
+
+// End mocks base method.
+func (m *MockSpan) End(err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "End", err)
+}
+
+// End indicates an expected call of End.
+func (mr *MockSpanMockRecorder) End(err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "End", reflect.TypeOf((*MockSpan)(nil).End), err)
+}
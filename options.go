@@ -10,17 +10,181 @@ type Option[J any, R any] func(*MicroBatcher[J, R])
 // WithFrequency sets the processing frequency.
 func WithFrequency[J any, R any](frequency time.Duration) Option[J, R] {
 	return func(mb *MicroBatcher[J, R]) {
-		mb.frequency = frequency
+		mb.frequency.Store(int64(frequency))
 	}
 }
 
 // WithBatchSize sets the batch size.
 func WithBatchSize[J any, R any](size int) Option[J, R] {
 	return func(mb *MicroBatcher[J, R]) {
-		mb.batchSize = size
-		mb.jobs = jobsC[J]{
-			c: make(chan Job[J], size),
+		mb.batchSize.Store(int64(size))
+	}
+}
+
+// WithAdaptiveBatchSize adjusts the batch size while the batcher runs, growing it while batches are processed
+// within the target latency and shrinking it when they are slow or fail, see AdaptiveBatchSize.
+// The batch size set by WithBatchSize is the starting size, BatchSize returns the current size.
+func WithAdaptiveBatchSize[J any, R any](adaptive AdaptiveBatchSize) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.adaptive = &adaptive
+	}
+}
+
+// WithBatchWeigher limits the cumulative weight of the jobs in a batch, e.g. their payload bytes.
+// A batch closes when either the batch size or maxWeight would be exceeded by the next job.
+// The weigher must always return the same weight for the same job.
+func WithBatchWeigher[J any, R any](weigher func(Job[J]) int, maxWeight int) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.weigher = weigher
+		mb.maxWeight = maxWeight
+	}
+}
+
+// WithOversizePolicy sets what happens to a job heavier than the maximum batch weight, by default it is rejected.
+func WithOversizePolicy[J any, R any](policy OversizePolicy) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.oversizePolicy = policy
+	}
+}
+
+// WithPartitionKey batches jobs by key, e.g. by tenant or shard, so every batch holds jobs of a single partition.
+// Each partition has its own queue, a partition holding the batch size is dispatched straight away and every
+// partition holding jobs is dispatched on each tick. Empty partitions are removed.
+// The queue capacity applies across all partitions and WithQueue is ignored.
+func WithPartitionKey[J any, R any](key func(Job[J]) string) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.partitionKey = key
+	}
+}
+
+// WithPriority queues jobs by priority, so jobs with a higher Priority are placed into the next batch first.
+// A job that has waited maxWait or longer is taken ahead of any priority so lower priorities still progress,
+// a maxWait of zero or less disables this guard. It is ignored with WithQueue and WithPartitionKey.
+func WithPriority[J any, R any](maxWait time.Duration) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.priority = true
+		mb.maxWait = maxWait
+	}
+}
+
+// WithDeduplication coalesces jobs with the same key: while a job with the key is pending, later jobs with the key
+// are not queued and their callers receive the result of the pending job under their own job ID.
+// A successful result is reused for jobs with the same key submitted within window of its completion,
+// a window of zero or less disables reuse.
+func WithDeduplication[J any, R any](key func(Job[J]) string, window time.Duration) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.dedup = newDedup[J, R](key, window)
+	}
+}
+
+// WithQueueCapacity sets the maximum number of jobs waiting to be processed,
+// by default it is the batch size or the maximum adaptive batch size if that is larger.
+// If capacity is not positive the queue is unbounded and submissions never wait for room.
+func WithQueueCapacity[J any, R any](capacity int) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		if capacity <= 0 {
+			capacity = -1
 		}
+		mb.queueCapacity = capacity
+	}
+}
+
+// WithMaxBatchesPerTick sets the maximum number of batches dispatched on each tick.
+// By default one batch is dispatched per tick, if max is not positive every tick drains the entire backlog.
+func WithMaxBatchesPerTick[J any, R any](max int) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.maxBatchesPerTick = max
+	}
+}
+
+// WithQueue sets the queue holding the jobs waiting to be processed, the queue capacity option is then ignored.
+func WithQueue[J any, R any](queue Queue[J]) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.queue = queue
+	}
+}
+
+// WithOverflowPolicy sets what happens to a submitted job when the queue is full, the default is OverflowBlock.
+func WithOverflowPolicy[J any, R any](policy OverflowPolicy) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.overflowPolicy = policy
+	}
+}
+
+// WithOverflowTimeout blocks submissions for up to timeout while the queue is full,
+// then rejects the job with ErrQueueFull. It sets the overflow policy to OverflowBlockTimeout.
+func WithOverflowTimeout[J any, R any](timeout time.Duration) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.overflowPolicy = OverflowBlockTimeout
+		mb.overflowTimeout = timeout
+	}
+}
+
+// WithConcurrency sets the maximum number of batches processed at once, the default is 1.
+// Batches are always dispatched in submission order, but with more than one batch in flight a later batch
+// may finish first and its results may be delivered before those of earlier jobs, see WithOrderedResults.
+func WithConcurrency[J any, R any](n int) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		if n < 1 {
+			n = 1
+		}
+		mb.concurrency = n
+	}
+}
+
+// WithOrderedResults delivers results in submission order, a batch that finishes early
+// holds back its results until every earlier batch has delivered its own.
+func WithOrderedResults[J any, R any]() Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.ordered = true
+	}
+}
+
+// WithProcessTimeout sets how long a batch may be processed. Once it has passed the batch's context is cancelled,
+// every job of the batch fails with ErrProcessTimeout and the batcher moves on, even if the processor never returns.
+func WithProcessTimeout[J any, R any](timeout time.Duration) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.processTimeout = timeout
+	}
+}
+
+// WithRetryPolicy retries jobs whose result holds an error according to the policy.
+// A retried job is queued again once its backoff has passed and processed in a later batch,
+// only the result of its final attempt is delivered.
+func WithRetryPolicy[J any, R any](policy RetryPolicy) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.retryPolicy = &policy
+	}
+}
+
+// WithDeadLetter sets a sink that receives every job that failed permanently, i.e. whose final result
+// from the processor holds an error, including jobs that exhausted their retries.
+func WithDeadLetter[J any, R any](deadLetter DeadLetter[J]) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.deadLetter = deadLetter
+	}
+}
+
+// WithUnmatchedResultHook sets a hook called for every result returned by the processor that does not match
+// a job of its batch, err is ErrUnknownJob or ErrDuplicateResult. Unmatched results are never delivered.
+func WithUnmatchedResultHook[J any, R any](hook func(result Result[R], err error)) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.unmatchedHook = hook
+	}
+}
+
+// WithMetrics sets the Metrics receiving measurements of the MicroBatcher, e.g. a MemoryMetrics.
+func WithMetrics[J any, R any](metrics Metrics) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.metrics = metrics
+	}
+}
+
+// WithTracer sets the Tracer tracing jobs from submission to result and every batch dispatched to the processor,
+// e.g. a MemoryTracer.
+func WithTracer[J any, R any](tracer Tracer) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.tracer = tracer
 	}
 }
 
@@ -30,3 +194,11 @@ func WithLogger[J any, R any](logger Logger) Option[J, R] {
 		mb.logger = logger
 	}
 }
+
+// WithStructuredLogger sets a leveled, structured logger for the MicroBatcher, e.g. a *slog.Logger.
+// It takes precedence over WithLogger.
+func WithStructuredLogger[J any, R any](logger StructuredLogger) Option[J, R] {
+	return func(mb *MicroBatcher[J, R]) {
+		mb.structured = logger
+	}
+}
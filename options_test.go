@@ -33,3 +33,37 @@ func TestWithLogger(t *testing.T) {
 	mb := embat.NewMicroBatcher[int, int](nil, embat.WithLogger[int, int](l))
 	assert.Equal(t, l, mb.Logger())
 }
+
+// TestWithConcurrency tests that the concurrency option can be set.
+func TestWithConcurrency(t *testing.T) {
+	mb := embat.NewMicroBatcher[int, int](nil, embat.WithConcurrency[int, int](4))
+	assert.Equal(t, 4, mb.Concurrency())
+
+	mb = embat.NewMicroBatcher[int, int](nil, embat.WithConcurrency[int, int](0))
+	assert.Equal(t, 1, mb.Concurrency())
+}
+
+// TestWithQueue tests that a custom queue can be set.
+func TestWithQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	q := mock.NewMockQueue[int](ctrl)
+	job := embat.NewJob(1)
+	q.EXPECT().Add(gomock.Any(), job).Return(nil).Times(1)
+	q.EXPECT().Len().Return(0).AnyTimes()
+	q.EXPECT().Close().Times(1)
+
+	mb := embat.NewMicroBatcher[int, int](nil, embat.WithQueue[int, int](q))
+	mb.Submit(job)
+	mb.Shutdown()
+}
+
+// TestWithStructuredLogger tests that a structured logger backs the Logger when no logger is set.
+func TestWithStructuredLogger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	l := mock.NewMockStructuredLogger(ctrl)
+	l.EXPECT().Debug("formatted 42").Times(1)
+	mb := embat.NewMicroBatcher[int, int](nil, embat.WithStructuredLogger[int, int](l))
+	mb.Logger().Debug("formatted %d", 42)
+}
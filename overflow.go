@@ -0,0 +1,37 @@
+package embat
+
+// OverflowPolicy decides what happens to a submitted job when the queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the submission until there is room in the queue, this is the default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowFail rejects the job straight away with ErrQueueFull.
+	OverflowFail
+	// OverflowDropOldest drops the oldest queued job to make room, the dropped job receives ErrDropped.
+	OverflowDropOldest
+	// OverflowBlockTimeout blocks the submission for up to the overflow timeout, then rejects the job with ErrQueueFull.
+	OverflowBlockTimeout
+)
+
+// String returns the name of the policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowFail:
+		return "fail"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowBlockTimeout:
+		return "block-timeout"
+	default:
+		return "unknown"
+	}
+}
+
+// oldestDropper is implemented by queues whose next job is not necessarily the oldest,
+// so OverflowDropOldest can still drop the oldest job.
+type oldestDropper[J any] interface {
+	dropOldest() (Job[J], bool)
+}
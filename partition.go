@@ -0,0 +1,249 @@
+package embat
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// queued is a job held by a partition queue together with when and in which order it was added.
+type queued[J any] struct {
+	seq   uint64
+	added time.Time
+	job   Job[J]
+}
+
+// partitionQueue is a Queue holding a first in, first out queue per partition key.
+// Next takes jobs from the partition whose oldest job has waited longest, so every batch holds a single partition.
+// A partition is removed as soon as it is empty, so idle keys hold no memory.
+type partitionQueue[J any] struct {
+	key func(Job[J]) string
+	// room holds a token for every queued job if the queue is bounded, it is nil if the queue is unbounded.
+	room chan struct{}
+
+	mu         sync.Mutex
+	seq        uint64
+	n          int
+	partitions map[string][]queued[J]
+}
+
+// newPartitionQueue returns a partition queue with room for capacity jobs across all partitions,
+// a negative capacity makes it unbounded.
+func newPartitionQueue[J any](key func(Job[J]) string, capacity int) *partitionQueue[J] {
+	q := &partitionQueue[J]{
+		key:        key,
+		partitions: make(map[string][]queued[J]),
+	}
+	if capacity >= 0 {
+		q.room = make(chan struct{}, capacity)
+	}
+	return q
+}
+
+// Add adds a job to its partition, waiting for room until ctx is done.
+func (q *partitionQueue[J]) Add(ctx context.Context, job Job[J]) error {
+	if q.room != nil {
+		select {
+		case q.room <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	q.push(job)
+	return nil
+}
+
+// TryAdd adds a job to its partition if the queue has room and reports whether it did.
+func (q *partitionQueue[J]) TryAdd(job Job[J]) bool {
+	if q.room != nil {
+		select {
+		case q.room <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	q.push(job)
+	return true
+}
+
+// push appends a job to its partition, the caller must have taken room for it.
+func (q *partitionQueue[J]) push(job Job[J]) {
+	key := q.key(job)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	q.partitions[key] = append(q.partitions[key], queued[J]{seq: q.seq, added: time.Now(), job: job})
+	q.n++
+}
+
+// Next removes and returns up to max jobs from the partition whose oldest job has waited longest.
+func (q *partitionQueue[J]) Next(max int) []Job[J] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	keys := q.keysLocked()
+	if len(keys) == 0 {
+		return []Job[J]{}
+	}
+	return q.takeLocked(keys[0], max)
+}
+
+// nextOf removes and returns up to max jobs from the given partition.
+func (q *partitionQueue[J]) nextOf(key string, max int) []Job[J] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.takeLocked(key, max)
+}
+
+// takeLocked removes up to max jobs from the given partition, the caller must hold the lock.
+func (q *partitionQueue[J]) takeLocked(key string, max int) []Job[J] {
+	p := q.partitions[key]
+	n := min(len(p), max)
+	batch := make([]Job[J], n)
+	for i := range batch {
+		batch[i] = p[i].job
+	}
+	if n == len(p) {
+		delete(q.partitions, key)
+	} else {
+		q.partitions[key] = p[n:]
+	}
+	q.n -= n
+	if q.room != nil {
+		for i := 0; i < n; i++ {
+			<-q.room
+		}
+	}
+	return batch
+}
+
+// Len returns the number of jobs across all partitions.
+func (q *partitionQueue[J]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// lenOf returns the number of jobs in the given partition.
+func (q *partitionQueue[J]) lenOf(key string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.partitions[key])
+}
+
+// keys returns the keys of the partitions holding jobs, the partition whose oldest job has waited longest first.
+func (q *partitionQueue[J]) keys() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.keysLocked()
+}
+
+// keysLocked returns the keys of the partitions holding jobs, oldest first, the caller must hold the lock.
+func (q *partitionQueue[J]) keysLocked() []string {
+	keys := make([]string, 0, len(q.partitions))
+	for key := range q.partitions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return q.partitions[keys[i]][0].seq < q.partitions[keys[j]][0].seq
+	})
+	return keys
+}
+
+// due returns the keys of the partitions whose oldest job was added at or before deadline, oldest first.
+func (q *partitionQueue[J]) due(deadline time.Time) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var keys []string
+	for _, key := range q.keysLocked() {
+		if q.partitions[key][0].added.After(deadline) {
+			break
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// oldest returns when the job that has waited longest across all partitions was added, ok is false if there is none.
+func (q *partitionQueue[J]) oldest() (added time.Time, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, p := range q.partitions {
+		if !ok || p[0].added.Before(added) {
+			added, ok = p[0].added, true
+		}
+	}
+	return added, ok
+}
+
+// full returns the key of a partition holding at least size jobs, if any.
+func (q *partitionQueue[J]) full(size int) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for key, p := range q.partitions {
+		if len(p) >= size {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// Close fulfills the interface but does nothing for this implementation.
+func (q *partitionQueue[J]) Close() {}
+
+// nextKey returns the partition the next batch is taken from: a full partition if there is one,
+// otherwise the partition whose oldest job has waited longest. Without partitioning it is always empty.
+func (mb *MicroBatcher[J, R]) nextKey() string {
+	if mb.partitions == nil {
+		return ""
+	}
+	if key, ok := mb.partitions.full(mb.BatchSize()); ok {
+		return key
+	}
+	if keys := mb.partitionKeys(); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// processDue dispatches a batch of every partition whose oldest job has waited the frequency or longer,
+// so a partition that keeps filling up and resetting the ticker cannot hold back the partial batches of others.
+func (mb *MicroBatcher[J, R]) processDue() {
+	if mb.partitions == nil {
+		return
+	}
+	for _, key := range mb.partitions.due(time.Now().Add(-mb.Frequency())) {
+		mb.processPartition(key)
+	}
+}
+
+// nextTick returns how long until the next tick, the frequency unless partitioned, in which case the tick
+// comes early enough for the job that has waited longest to wait no more than the frequency.
+func (mb *MicroBatcher[J, R]) nextTick() time.Duration {
+	frequency := mb.Frequency()
+	if mb.partitions == nil {
+		return frequency
+	}
+	added, ok := mb.partitions.oldest()
+	if !ok {
+		return frequency
+	}
+	return max(min(frequency, time.Until(added.Add(frequency))), time.Millisecond)
+}
+
+// partitionKeys returns the keys of the partitions holding jobs, including jobs held back by the batch weigher.
+func (mb *MicroBatcher[J, R]) partitionKeys() []string {
+	keys := mb.partitions.keys()
+	for key := range mb.carry {
+		if mb.partitions.lenOf(key) == 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// hasJobs returns true if the partition holds jobs, including a job held back by the batch weigher.
+func (mb *MicroBatcher[J, R]) hasJobs(key string) bool {
+	_, carried := mb.carry[key]
+	return carried || mb.partitions.lenOf(key) > 0
+}
@@ -0,0 +1,96 @@
+package embat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// partitionJob returns a job for the given partition.
+func partitionJob(key string, i int) Job[string] {
+	return Job[string]{ID: JobID(fmt.Sprint(key, i)), Data: key}
+}
+
+// Test_partitionQueue tests that jobs are queued by partition and taken from the oldest partition first.
+func Test_partitionQueue(t *testing.T) {
+	q := newPartitionQueue(func(job Job[string]) string { return job.Data }, -1)
+	for i, key := range []string{"a", "b", "a", "c", "b", "a"} {
+		assert.True(t, q.TryAdd(partitionJob(key, i)))
+	}
+	assert.Equal(t, 6, q.Len())
+	assert.Equal(t, []string{"a", "b", "c"}, q.keys())
+	assert.Equal(t, 3, q.lenOf("a"))
+
+	key, ok := q.full(3)
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+	_, ok = q.full(4)
+	assert.False(t, ok)
+
+	batch := q.Next(2)
+	assert.Equal(t, []JobID{"a0", "a2"}, jobIDs(batch))
+	// The remaining job of partition a is newer than the oldest job of partition b.
+	assert.Equal(t, []string{"b", "c", "a"}, q.keys())
+
+	batch = q.nextOf("c", 10)
+	assert.Equal(t, []JobID{"c3"}, jobIDs(batch))
+	assert.Equal(t, 0, q.lenOf("c"))
+	assert.NotContains(t, q.partitions, "c")
+	assert.Equal(t, 3, q.Len())
+}
+
+// Test_partitionQueue_capacity tests that the capacity is shared across partitions.
+func Test_partitionQueue_capacity(t *testing.T) {
+	q := newPartitionQueue(func(job Job[string]) string { return job.Data }, 2)
+	assert.True(t, q.TryAdd(partitionJob("a", 0)))
+	assert.NoError(t, q.Add(context.Background(), partitionJob("b", 1)))
+	assert.False(t, q.TryAdd(partitionJob("c", 2)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Add(ctx, partitionJob("c", 2)), context.DeadlineExceeded)
+
+	q.nextOf("b", 1)
+	assert.True(t, q.TryAdd(partitionJob("c", 2)))
+}
+
+// Test_partitionQueue_due tests that partitions are due once their oldest job was added before the deadline.
+func Test_partitionQueue_due(t *testing.T) {
+	q := newPartitionQueue(func(job Job[string]) string { return job.Data }, -1)
+	_, ok := q.oldest()
+	assert.False(t, ok)
+	assert.True(t, q.TryAdd(partitionJob("a", 0)))
+	assert.True(t, q.TryAdd(partitionJob("b", 1)))
+	time.Sleep(20 * time.Millisecond)
+	deadline := time.Now()
+	assert.True(t, q.TryAdd(partitionJob("c", 2)))
+	assert.True(t, q.TryAdd(partitionJob("a", 3)))
+
+	assert.Equal(t, []string{"a", "b"}, q.due(deadline))
+	oldest, ok := q.oldest()
+	assert.True(t, ok)
+	assert.True(t, oldest.Before(deadline))
+
+	q.nextOf("a", 2)
+	q.nextOf("b", 1)
+	assert.Empty(t, q.due(deadline))
+}
+
+// Test_processTick_partitions tests that each tick dispatches a batch for every partition holding jobs.
+func Test_processTick_partitions(t *testing.T) {
+	p := &countingProcessor{}
+	mb := newTestBatcher(p, 2)
+	mb.partitions = newPartitionQueue(func(job Job[int]) string { return fmt.Sprint(job.Data % 3) }, -1)
+	mb.jobs = mb.partitions
+	for i := 0; i < 7; i++ {
+		mb.Submit(NewJob(i))
+	}
+	mb.processTick()
+	mb.workers.Wait()
+	// Partition 0 holds 0, 3 and 6, partition 1 holds 1 and 4, partition 2 holds 2 and 5.
+	assert.Equal(t, []int{2, 2, 2}, p.sizes)
+	assert.Equal(t, 1, mb.jobs.Len())
+}
@@ -0,0 +1,170 @@
+package embat
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// prioritized is a job held by a priority queue together with when and in which order it was added.
+type prioritized[J any] struct {
+	seq   uint64
+	added time.Time
+	job   Job[J]
+}
+
+// priorityQueue is a Queue holding a first in, first out lane per job priority.
+type priorityQueue[J any] struct {
+	// maxWait is how long a job may wait before it is taken ahead of higher priorities, zero disables the guard.
+	maxWait time.Duration
+	// room holds a token for every queued job if the queue is bounded, it is nil if the queue is unbounded.
+	room chan struct{}
+
+	mu    sync.Mutex
+	seq   uint64
+	n     int
+	lanes map[int][]prioritized[J]
+	// priorities holds the priorities of the lanes holding jobs, highest first.
+	priorities []int
+}
+
+// NewPriorityQueue returns a Queue that returns jobs with a higher Priority first and jobs of the same priority
+// first in, first out, so it is not first in, first out across priorities. To keep lower priorities progressing,
+// a job that has waited maxWait or longer is taken ahead of any priority, oldest first.
+// A maxWait of zero or less disables this guard. A capacity of zero or less makes the queue unbounded.
+// With OverflowDropOldest the oldest job of any priority is dropped to make room.
+func NewPriorityQueue[J any](capacity int, maxWait time.Duration) Queue[J] {
+	q := &priorityQueue[J]{
+		maxWait: maxWait,
+		lanes:   make(map[int][]prioritized[J]),
+	}
+	if capacity > 0 {
+		q.room = make(chan struct{}, capacity)
+	}
+	return q
+}
+
+// Add adds a job to the lane of its priority, waiting for room until ctx is done.
+func (q *priorityQueue[J]) Add(ctx context.Context, job Job[J]) error {
+	if q.room != nil {
+		select {
+		case q.room <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	q.push(job)
+	return nil
+}
+
+// TryAdd adds a job to the lane of its priority if the queue has room and reports whether it did.
+func (q *priorityQueue[J]) TryAdd(job Job[J]) bool {
+	if q.room != nil {
+		select {
+		case q.room <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	q.push(job)
+	return true
+}
+
+// push appends a job to the lane of its priority, the caller must have taken room for it.
+func (q *priorityQueue[J]) push(job Job[J]) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	lane, ok := q.lanes[job.Priority]
+	if !ok {
+		i := sort.Search(len(q.priorities), func(i int) bool { return q.priorities[i] < job.Priority })
+		q.priorities = append(q.priorities, 0)
+		copy(q.priorities[i+1:], q.priorities[i:])
+		q.priorities[i] = job.Priority
+	}
+	q.lanes[job.Priority] = append(lane, prioritized[J]{seq: q.seq, added: time.Now(), job: job})
+	q.n++
+}
+
+// Next removes and returns up to max jobs, jobs that have waited too long first, then by priority.
+func (q *priorityQueue[J]) Next(max int) []Job[J] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	batch := make([]Job[J], 0, min(max, q.n))
+	now := time.Now()
+	for len(batch) < max && q.n > 0 {
+		batch = append(batch, q.popLocked(q.pickLocked(now)))
+	}
+	if q.room != nil {
+		for range batch {
+			<-q.room
+		}
+	}
+	return batch
+}
+
+// dropOldest removes and returns the job that was added first regardless of its priority,
+// ok is false if the queue is empty.
+func (q *priorityQueue[J]) dropOldest() (job Job[J], ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.n == 0 {
+		return job, false
+	}
+	oldest := q.priorities[0]
+	for _, priority := range q.priorities[1:] {
+		if q.lanes[priority][0].seq < q.lanes[oldest][0].seq {
+			oldest = priority
+		}
+	}
+	job = q.popLocked(oldest)
+	if q.room != nil {
+		<-q.room
+	}
+	return job, true
+}
+
+// pickLocked returns the priority of the lane to take the next job from, the caller must hold the lock
+// and the queue must not be empty.
+func (q *priorityQueue[J]) pickLocked(now time.Time) int {
+	if q.maxWait > 0 {
+		starved, found := 0, false
+		var oldest uint64
+		for _, priority := range q.priorities {
+			head := q.lanes[priority][0]
+			if now.Sub(head.added) >= q.maxWait && (!found || head.seq < oldest) {
+				starved, oldest, found = priority, head.seq, true
+			}
+		}
+		if found {
+			return starved
+		}
+	}
+	return q.priorities[0]
+}
+
+// popLocked removes the first job of the given lane, the caller must hold the lock.
+func (q *priorityQueue[J]) popLocked(priority int) Job[J] {
+	lane := q.lanes[priority]
+	job := lane[0].job
+	if len(lane) == 1 {
+		delete(q.lanes, priority)
+		i := sort.Search(len(q.priorities), func(i int) bool { return q.priorities[i] <= priority })
+		q.priorities = append(q.priorities[:i], q.priorities[i+1:]...)
+	} else {
+		q.lanes[priority] = lane[1:]
+	}
+	q.n--
+	return job
+}
+
+// Len returns the number of jobs across all lanes.
+func (q *priorityQueue[J]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// Close fulfills the interface but does nothing for this implementation.
+func (q *priorityQueue[J]) Close() {}
@@ -0,0 +1,56 @@
+package embat
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// priorityJob returns a job with the given priority.
+func priorityJob(i, priority int) Job[int] {
+	return Job[int]{ID: JobID(fmt.Sprint(i)), Data: i, Priority: priority}
+}
+
+// Test_priorityQueue tests that jobs are returned by priority, first in first out within a priority.
+func Test_priorityQueue(t *testing.T) {
+	q := NewPriorityQueue[int](0, 0)
+	for i, priority := range []int{0, 1, 0, 2, 1, -1} {
+		assert.True(t, q.TryAdd(priorityJob(i, priority)))
+	}
+	assert.Equal(t, 6, q.Len())
+	assert.Equal(t, []JobID{"3", "1", "4"}, jobIDs(q.Next(3)))
+	assert.Equal(t, []JobID{"0", "2", "5"}, jobIDs(q.Next(10)))
+	assert.Equal(t, 0, q.Len())
+	assert.Empty(t, q.(*priorityQueue[int]).priorities)
+}
+
+// Test_priorityQueue_dropOldest tests that the oldest job is dropped regardless of its priority.
+func Test_priorityQueue_dropOldest(t *testing.T) {
+	q := NewPriorityQueue[int](2, 0).(*priorityQueue[int])
+	_, ok := q.dropOldest()
+	assert.False(t, ok)
+	assert.True(t, q.TryAdd(priorityJob(0, 0)))
+	assert.True(t, q.TryAdd(priorityJob(1, 10)))
+	assert.False(t, q.TryAdd(priorityJob(2, 5)))
+
+	job, ok := q.dropOldest()
+	assert.True(t, ok)
+	assert.Equal(t, JobID("0"), job.ID)
+	assert.True(t, q.TryAdd(priorityJob(2, 5)))
+	assert.Equal(t, []JobID{"1", "2"}, jobIDs(q.Next(2)))
+}
+
+// Test_priorityQueue_starvation tests that jobs that have waited too long are taken ahead of higher priorities.
+func Test_priorityQueue_starvation(t *testing.T) {
+	q := NewPriorityQueue[int](0, 20*time.Millisecond)
+	assert.True(t, q.TryAdd(priorityJob(0, 0)))
+	assert.True(t, q.TryAdd(priorityJob(1, -1)))
+	assert.True(t, q.TryAdd(priorityJob(2, 0)))
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, q.TryAdd(priorityJob(3, 5)))
+
+	// The starved jobs go first oldest first regardless of priority, then the fresh high priority job.
+	assert.Equal(t, []JobID{"0", "1", "2", "3"}, jobIDs(q.Next(4)))
+}
@@ -0,0 +1,190 @@
+// Package promtext exposes the metrics of embat batchers in the Prometheus text exposition format,
+// without depending on the Prometheus client library.
+package promtext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nayanbhana/embat"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ErrDuplicateName is returned when registering a batcher under a name that is already registered.
+var ErrDuplicateName = errors.New("batcher name already registered")
+
+// source is the metrics of a batcher registered under a name.
+type source struct {
+	name    string
+	metrics *embat.MemoryMetrics
+}
+
+// Handler is an http.Handler serving the metrics of registered batchers, every sample is labelled with
+// the name the batcher was registered under, so multiple batchers in one process can be distinguished.
+type Handler struct {
+	mu      sync.Mutex
+	sources []source
+}
+
+// NewHandler creates a Handler without any registered batchers.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Register adds the metrics of a batcher under the given name, which becomes the value of the batcher label.
+func (h *Handler) Register(name string, metrics *embat.MemoryMetrics) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.sources {
+		if s.name == name {
+			return fmt.Errorf("%w: %s", ErrDuplicateName, name)
+		}
+	}
+	h.sources = append(h.sources, source{name: name, metrics: metrics})
+	sort.Slice(h.sources, func(i, j int) bool { return h.sources[i].name < h.sources[j].name })
+	return nil
+}
+
+// Unregister removes the metrics of the batcher registered under the given name, if any.
+func (h *Handler) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, s := range h.sources {
+		if s.name == name {
+			h.sources = append(h.sources[:i], h.sources[i+1:]...)
+			return
+		}
+	}
+}
+
+// ServeHTTP writes the metrics of every registered batcher.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = h.Write(w)
+}
+
+// Write writes the metrics of every registered batcher to w.
+func (h *Handler) Write(w io.Writer) error {
+	h.mu.Lock()
+	snapshots := make([]snapshot, len(h.sources))
+	for i, s := range h.sources {
+		snapshots[i] = snapshot{name: s.name, MetricsSnapshot: s.metrics.Snapshot()}
+	}
+	h.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, s := range snapshots {
+			label := `batcher="` + labelEscaper.Replace(s.name) + `"`
+			if f.histogram != nil {
+				writeHistogram(&b, f.name, label, f.histogram(s.MetricsSnapshot))
+				continue
+			}
+			fmt.Fprintf(&b, "%s{%s} %s\n", f.name, label, formatFloat(f.value(s.MetricsSnapshot)))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// snapshot is the snapshot of the metrics of a named batcher.
+type snapshot struct {
+	name string
+	embat.MetricsSnapshot
+}
+
+// family describes a metric family and how to read its samples from a snapshot.
+type family struct {
+	name      string
+	help      string
+	kind      string
+	value     func(embat.MetricsSnapshot) float64
+	histogram func(embat.MetricsSnapshot) embat.Histogram
+}
+
+// families are the metric families written for every batcher.
+var families = []family{
+	{
+		name:  "embat_queue_depth",
+		help:  "Number of jobs waiting to be processed.",
+		kind:  "gauge",
+		value: func(s embat.MetricsSnapshot) float64 { return float64(s.QueueDepth) },
+	},
+	{
+		name:  "embat_jobs_submitted_total",
+		help:  "Number of jobs accepted by the batcher.",
+		kind:  "counter",
+		value: func(s embat.MetricsSnapshot) float64 { return float64(s.Submitted) },
+	},
+	{
+		name:  "embat_jobs_rejected_total",
+		help:  "Number of jobs the batcher did not accept.",
+		kind:  "counter",
+		value: func(s embat.MetricsSnapshot) float64 { return float64(s.Rejected) },
+	},
+	{
+		name:  "embat_jobs_retried_total",
+		help:  "Number of failed jobs scheduled for another attempt.",
+		kind:  "counter",
+		value: func(s embat.MetricsSnapshot) float64 { return float64(s.Retried) },
+	},
+	{
+		name:  "embat_jobs_completed_total",
+		help:  "Number of accepted jobs that received their result.",
+		kind:  "counter",
+		value: func(s embat.MetricsSnapshot) float64 { return float64(s.Completed) },
+	},
+	{
+		name:  "embat_jobs_failed_total",
+		help:  "Number of accepted jobs whose result held an error.",
+		kind:  "counter",
+		value: func(s embat.MetricsSnapshot) float64 { return float64(s.Failed) },
+	},
+	{
+		name:      "embat_batch_size",
+		help:      "Number of jobs per batch dispatched to the processor.",
+		kind:      "histogram",
+		histogram: func(s embat.MetricsSnapshot) embat.Histogram { return s.BatchSize },
+	},
+	{
+		name:      "embat_process_duration_seconds",
+		help:      "Time the processor took per batch.",
+		kind:      "histogram",
+		histogram: func(s embat.MetricsSnapshot) embat.Histogram { return s.ProcessDuration },
+	},
+	{
+		name:      "embat_job_latency_seconds",
+		help:      "Time from submitting a job to its result.",
+		kind:      "histogram",
+		histogram: func(s embat.MetricsSnapshot) embat.Histogram { return s.Latency },
+	},
+}
+
+// writeHistogram writes the cumulative buckets, sum and count of a histogram.
+func writeHistogram(b *strings.Builder, name, label string, h embat.Histogram) {
+	var cumulative uint64
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, label, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, label, h.Count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, label, formatFloat(h.Sum))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, label, h.Count)
+}
+
+// formatFloat formats a sample value in its shortest representation.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelEscaper escapes backslashes, double quotes and newlines in label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,64 @@
+package promtext_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nayanbhana/embat"
+	"github.com/nayanbhana/embat/promtext"
+)
+
+// TestHandler tests that the metrics of every registered batcher are served labelled by its name.
+func TestHandler(t *testing.T) {
+	orders := embat.NewMemoryMetrics()
+	orders.QueueDepth(3)
+	orders.JobSubmitted()
+	orders.BatchDispatched(2)
+	orders.BatchProcessed(2, 30*time.Millisecond)
+	emails := embat.NewMemoryMetrics()
+	emails.JobRetried()
+
+	h := promtext.NewHandler()
+	require.NoError(t, h.Register("orders", orders))
+	require.NoError(t, h.Register("emails", emails))
+	assert.ErrorIs(t, h.Register("orders", orders), promtext.ErrDuplicateName)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, promtext.ContentType, rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+
+	assert.Contains(t, body, "# HELP embat_queue_depth Number of jobs waiting to be processed.\n"+
+		"# TYPE embat_queue_depth gauge\n"+
+		"embat_queue_depth{batcher=\"emails\"} 0\n"+
+		"embat_queue_depth{batcher=\"orders\"} 3\n")
+	assert.Contains(t, body, "embat_jobs_submitted_total{batcher=\"orders\"} 1\n")
+	assert.Contains(t, body, "embat_jobs_retried_total{batcher=\"emails\"} 1\n")
+	assert.Contains(t, body, "# TYPE embat_batch_size histogram\n")
+	assert.Contains(t, body, "embat_batch_size_bucket{batcher=\"orders\",le=\"1\"} 0\n"+
+		"embat_batch_size_bucket{batcher=\"orders\",le=\"2\"} 1\n"+
+		"embat_batch_size_bucket{batcher=\"orders\",le=\"5\"} 1\n")
+	assert.Contains(t, body, "embat_batch_size_bucket{batcher=\"orders\",le=\"+Inf\"} 1\n"+
+		"embat_batch_size_sum{batcher=\"orders\"} 2\n"+
+		"embat_batch_size_count{batcher=\"orders\"} 1\n")
+	assert.Contains(t, body, "embat_process_duration_seconds_bucket{batcher=\"orders\",le=\"0.05\"} 1\n")
+	assert.Contains(t, body, "embat_process_duration_seconds_sum{batcher=\"orders\"} 0.03\n")
+
+	h.Unregister("emails")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.NotContains(t, rec.Body.String(), "emails")
+}
+
+// TestHandler_escapes_names tests that batcher names are escaped in label values.
+func TestHandler_escapes_names(t *testing.T) {
+	h := promtext.NewHandler()
+	require.NoError(t, h.Register("a\"b\\c\nd", embat.NewMemoryMetrics()))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `embat_queue_depth{batcher="a\"b\\c\nd"} 0`)
+}
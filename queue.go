@@ -0,0 +1,31 @@
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+package embat
+
+import (
+	"context"
+)
+
+// Queue holds the jobs waiting to be processed, it can be implemented by the consumer and supplied with WithQueue.
+// Implementations must be safe for concurrent use, the queuetest package checks that an implementation conforms.
+type Queue[J any] interface {
+	// Add adds a job to the queue, waiting for room until ctx is done in which case ctx.Err() is returned.
+	Add(ctx context.Context, job Job[J]) error
+	// TryAdd adds a job to the queue if it has room without waiting and reports whether it did.
+	TryAdd(job Job[J]) bool
+	// Next removes and returns up to max jobs from the queue, the returned slice is empty if there are none.
+	Next(max int) []Job[J]
+	// Len returns the number of jobs in the queue.
+	Len() int
+	// Close is called once the batcher has shut down, no jobs are added afterwards.
+	Close()
+}
+
+// NewChanQueue returns a first in, first out Queue backed by a channel with room for capacity jobs.
+func NewChanQueue[J any](capacity int) Queue[J] {
+	return jobsC[J]{c: make(chan Job[J], capacity)}
+}
+
+// NewSliceQueue returns an unbounded first in, first out Queue backed by a slice.
+func NewSliceQueue[J any]() Queue[J] {
+	return &jobsS[J]{}
+}
@@ -0,0 +1,35 @@
+package embat_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nayanbhana/embat"
+	"github.com/nayanbhana/embat/queuetest"
+)
+
+// TestNewChanQueue tests that the channel queue conforms to the Queue interface.
+func TestNewChanQueue(t *testing.T) {
+	queuetest.Run(t, func() embat.Queue[int] {
+		return embat.NewChanQueue[int](5)
+	}, 5)
+}
+
+// TestNewSliceQueue tests that the slice queue conforms to the Queue interface.
+func TestNewSliceQueue(t *testing.T) {
+	queuetest.Run(t, func() embat.Queue[int] {
+		return embat.NewSliceQueue[int]()
+	}, 0)
+}
+
+// TestNewPriorityQueue tests that the priority queue conforms to the Queue interface.
+// The suite only adds jobs of the default priority, so it checks the queue is first in, first out within a priority,
+// ordering across priorities is tested in priority_test.go.
+func TestNewPriorityQueue(t *testing.T) {
+	queuetest.Run(t, func() embat.Queue[int] {
+		return embat.NewPriorityQueue[int](5, time.Minute)
+	}, 5)
+	queuetest.Run(t, func() embat.Queue[int] {
+		return embat.NewPriorityQueue[int](0, 0)
+	}, 0)
+}
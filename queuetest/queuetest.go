@@ -0,0 +1,164 @@
+// Package queuetest provides a conformance test suite for embat.Queue implementations.
+package queuetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nayanbhana/embat"
+)
+
+// Run runs the conformance test suite against queues returned by newQueue, which must return a new empty queue
+// on every call. capacity is the number of jobs each queue has room for, or zero if the queues are unbounded.
+func Run(t *testing.T, newQueue func() embat.Queue[int], capacity int) {
+	t.Run("empty", func(t *testing.T) {
+		testEmpty(t, newQueue())
+	})
+	t.Run("first in first out", func(t *testing.T) {
+		testFIFO(t, newQueue(), capacity)
+	})
+	t.Run("next respects max", func(t *testing.T) {
+		testNextMax(t, newQueue(), capacity)
+	})
+	t.Run("concurrent add", func(t *testing.T) {
+		testConcurrentAdd(t, newQueue(), capacity)
+	})
+	if capacity > 0 {
+		t.Run("full", func(t *testing.T) {
+			testFull(t, newQueue(), capacity)
+		})
+		t.Run("add waits for room", func(t *testing.T) {
+			testAddWaits(t, newQueue(), capacity)
+		})
+	}
+	t.Run("close", func(t *testing.T) {
+		q := newQueue()
+		assert.True(t, q.TryAdd(newJob(0)))
+		q.Next(1)
+		assert.NotPanics(t, q.Close)
+	})
+}
+
+// testEmpty tests that an empty queue has no jobs.
+func testEmpty(t *testing.T, q embat.Queue[int]) {
+	assert.Equal(t, 0, q.Len())
+	assert.Empty(t, q.Next(10))
+}
+
+// testFIFO tests that jobs are returned in the order they were added.
+func testFIFO(t *testing.T, q embat.Queue[int], capacity int) {
+	n := size(capacity)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			assert.NoError(t, q.Add(context.Background(), newJob(i)))
+		} else {
+			assert.True(t, q.TryAdd(newJob(i)))
+		}
+	}
+	assert.Equal(t, n, q.Len())
+
+	batch := q.Next(n)
+	assert.Len(t, batch, n)
+	for i, job := range batch {
+		assert.Equal(t, i, job.Data)
+	}
+	assert.Equal(t, 0, q.Len())
+}
+
+// testNextMax tests that Next returns at most max jobs and leaves the rest queued.
+func testNextMax(t *testing.T, q embat.Queue[int], capacity int) {
+	n := size(capacity)
+	for i := 0; i < n; i++ {
+		assert.True(t, q.TryAdd(newJob(i)))
+	}
+
+	batch := q.Next(n - 1)
+	assert.Len(t, batch, n-1)
+	assert.Equal(t, 1, q.Len())
+
+	batch = q.Next(n)
+	assert.Len(t, batch, 1)
+	assert.Equal(t, n-1, batch[0].Data)
+	assert.Equal(t, 0, q.Len())
+}
+
+// testConcurrentAdd tests that jobs added concurrently are each returned exactly once.
+func testConcurrentAdd(t *testing.T, q embat.Queue[int], capacity int) {
+	n := size(capacity)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, q.Add(context.Background(), newJob(i)))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int]bool)
+	for _, job := range q.Next(n) {
+		assert.False(t, seen[job.Data], "job %v returned twice", job.Data)
+		seen[job.Data] = true
+	}
+	assert.Len(t, seen, n)
+}
+
+// testFull tests that a full queue rejects jobs until room is made.
+func testFull(t *testing.T, q embat.Queue[int], capacity int) {
+	for i := 0; i < capacity; i++ {
+		assert.True(t, q.TryAdd(newJob(i)))
+	}
+	assert.False(t, q.TryAdd(newJob(capacity)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Add(ctx, newJob(capacity)), context.DeadlineExceeded)
+	assert.Equal(t, capacity, q.Len())
+
+	q.Next(1)
+	assert.True(t, q.TryAdd(newJob(capacity)))
+}
+
+// testAddWaits tests that Add on a full queue waits until Next makes room.
+func testAddWaits(t *testing.T, q embat.Queue[int], capacity int) {
+	for i := 0; i < capacity; i++ {
+		assert.True(t, q.TryAdd(newJob(i)))
+	}
+
+	added := make(chan error, 1)
+	go func() {
+		added <- q.Add(context.Background(), newJob(capacity))
+	}()
+	select {
+	case <-added:
+		t.Fatal("expected Add to wait for room")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	q.Next(1)
+	select {
+	case err := <-added:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected Add to succeed once there was room")
+	}
+	assert.Equal(t, capacity, q.Len())
+}
+
+// size returns how many jobs the tests add to a queue.
+func size(capacity int) int {
+	if capacity > 0 && capacity < 10 {
+		return capacity
+	}
+	return 10
+}
+
+// newJob returns a job holding i.
+func newJob(i int) embat.Job[int] {
+	return embat.Job[int]{ID: embat.JobID(fmt.Sprint(i)), Data: i}
+}
@@ -1,31 +1,269 @@
 package embat
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // results holds a map of the results of processed jobs.
 type results[R any] struct {
 	mu sync.Mutex
-	m  map[JobID]chan Result[R]
+	m  map[JobID]*pending[R]
+	// observe is called with every delivered result sent on a channel and the time since its job was added, if set.
+	observe func(latency time.Duration, err error)
+}
+
+// pending tracks a submitted job until its result has been delivered.
+type pending[R any] struct {
+	// ch receives the result of the job.
+	ch chan Result[R]
+	// done receives the result of the job instead of ch, if set, rejected is true if the job could not be queued.
+	done func(result Result[R], rejected bool)
+	// added is when the job was added.
+	added time.Time
+	// span traces the job, if set.
+	span Span
+	// stop detaches the job from its submission context, if any.
+	stop func() bool
+	// batch is the in-flight batch the job was dispatched in, if any.
+	batch *inflight
+	// attempts records every failed attempt at processing the job.
+	attempts []Attempt
+}
+
+// inflight tracks how many jobs of a dispatched batch are still awaited by their callers.
+type inflight struct {
+	remaining int
+	cancel    context.CancelFunc
 }
 
 // add safely adds a new job result channel to the results map
 func (r *results[R]) add(jobID JobID, ch chan Result[R]) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.m[jobID] = ch
+	r.m[jobID] = &pending[R]{ch: ch, added: time.Now()}
+}
+
+// addFunc adds a job whose result is passed to done instead of being sent on a channel,
+// done is called with the lock held and must not call back into the results.
+func (r *results[R]) addFunc(jobID JobID, done func(result Result[R], rejected bool)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m[jobID] = &pending[R]{done: done, added: time.Now()}
+}
+
+// watch attaches the stop func of a job's context watcher, calling it straight away if the job is already done.
+func (r *results[R]) watch(jobID JobID, stop func() bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.m[jobID]
+	if !ok {
+		stop()
+		return
+	}
+	p.stop = stop
+}
+
+// trace attaches the span tracing a job.
+func (r *results[R]) trace(jobID JobID, span Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.m[jobID]; ok {
+		p.span = span
+	}
+}
+
+// spans returns the spans of the given jobs, nil for jobs that are no longer pending or not traced.
+func (r *results[R]) spans(jobIDs []JobID) []Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]Span, len(jobIDs))
+	for i, id := range jobIDs {
+		if p, ok := r.m[id]; ok {
+			spans[i] = p.span
+		}
+	}
+	return spans
+}
+
+// len returns the number of jobs awaiting their result.
+func (r *results[R]) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.m)
+}
+
+// isPending returns true if the job is still awaiting its result.
+func (r *results[R]) isPending(jobID JobID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.m[jobID]
+	return ok
+}
+
+// track records that the given jobs were dispatched together,
+// cancel is called once every job in the batch has been abandoned.
+func (r *results[R]) track(jobIDs []JobID, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := &inflight{cancel: cancel}
+	for _, id := range jobIDs {
+		if p, ok := r.m[id]; ok {
+			p.batch = b
+			b.remaining++
+		}
+	}
+	if b.remaining == 0 {
+		cancel()
+	}
 }
 
-// sendResults sends the results of processed jobs to the respective result channels.
-func (r *results[R]) sendResults(jobResults []Result[R]) {
+// abandon delivers err to a job that is still pending, returning true if it was.
+// If every job in the job's batch has now been abandoned the batch is cancelled.
+func (r *results[R]) abandon(jobID JobID, err error) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	p, ok := r.m[jobID]
+	if !ok {
+		return false
+	}
+	r.finish(p, Result[R]{JobID: jobID, Err: err})
+	if p.batch != nil {
+		p.batch.remaining--
+		if p.batch.remaining == 0 {
+			p.batch.cancel()
+		}
+	}
+	return true
+}
+
+// reject delivers err to a job that could not be queued, without observing it as completed,
+// returning true if the job was still pending, i.e. it had not been withdrawn meanwhile.
+func (r *results[R]) reject(jobID JobID, err error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.m[jobID]
+	if !ok {
+		return false
+	}
+	r.deliver(p, Result[R]{JobID: jobID, Err: err}, true)
+	return true
+}
+
+// unmatched is a result that could not be matched to a job of its batch.
+type unmatched[R any] struct {
+	result Result[R]
+	err    error
+}
+
+// failure is a job whose final result held an error.
+type failure struct {
+	jobID    JobID
+	err      error
+	attempts []Attempt
+}
+
+// outcome summarises the results of a processed batch.
+type outcome[R any] struct {
+	// missing holds the pending jobs the processor returned no result for.
+	missing []JobID
+	// unmatched holds results for jobs outside the batch and repeated results for the same job.
+	unmatched []unmatched[R]
+	// failed holds the jobs that received an error as their final result.
+	failed []failure
+}
+
+// sendResults sends the results of a processed batch to the respective result channels.
+// Pending jobs of the batch without a result receive ErrNoResult, results that match no job of the batch are not sent.
+// If retry is not nil it is called with every error result of a pending job, the result is not sent if it returns true.
+func (r *results[R]) sendResults(jobIDs []JobID, jobResults []Result[R], retry func(result Result[R]) bool) outcome[R] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var o outcome[R]
+	answered := make(map[JobID]bool, len(jobIDs))
+	for _, id := range jobIDs {
+		answered[id] = false
+	}
 	for _, result := range jobResults {
-		if ch, ok := r.m[result.JobID]; ok {
-			ch <- result
-			close(ch)
-			delete(r.m, result.JobID)
+		done, ok := answered[result.JobID]
+		switch {
+		case !ok:
+			o.unmatched = append(o.unmatched, unmatched[R]{result: result, err: ErrUnknownJob})
+		case done:
+			o.unmatched = append(o.unmatched, unmatched[R]{result: result, err: ErrDuplicateResult})
+		default:
+			answered[result.JobID] = true
+			if p, ok := r.m[result.JobID]; ok {
+				r.complete(p, result, retry, &o)
+			}
+		}
+	}
+	for _, id := range jobIDs {
+		if answered[id] {
+			continue
 		}
+		if p, ok := r.m[id]; ok {
+			o.missing = append(o.missing, id)
+			r.complete(p, Result[R]{JobID: id, Err: ErrNoResult}, retry, &o)
+		}
+	}
+	return o
+}
+
+// complete records the result of an attempt at a pending job and sends it unless the job is retried,
+// the caller must hold the lock.
+func (r *results[R]) complete(p *pending[R], result Result[R], retry func(result Result[R]) bool, o *outcome[R]) {
+	if result.Err != nil {
+		p.attempts = append(p.attempts, Attempt{Attempt: len(p.attempts) + 1, Err: result.Err, Time: time.Now()})
+		if retry != nil && retry(result) {
+			if p.span != nil {
+				p.span.AddEvent(EventRetrying, KeyAttempt, len(p.attempts), KeyError, result.Err)
+			}
+			return
+		}
+		o.failed = append(o.failed, failure{jobID: result.JobID, err: result.Err, attempts: p.attempts})
+	}
+	r.finish(p, result)
+}
+
+// failAll delivers err to every pending job and returns how many were failed.
+func (r *results[R]) failAll(err error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.m)
+	for id, p := range r.m {
+		r.finish(p, Result[R]{JobID: id, Err: err})
+	}
+	return n
+}
+
+// finish delivers the result and observes it, the caller must hold the lock.
+// Results passed to a done func are observed by its owner.
+func (r *results[R]) finish(p *pending[R], result Result[R]) {
+	if p.span != nil {
+		p.span.AddEvent(EventCompleted)
+	}
+	r.deliver(p, result, false)
+	if r.observe != nil && p.done == nil {
+		r.observe(time.Since(p.added), result.Err)
+	}
+}
+
+// deliver sends the result to the job's channel or done func and forgets the job, the caller must hold the lock.
+// rejected is passed on to the done func.
+func (r *results[R]) deliver(p *pending[R], result Result[R], rejected bool) {
+	if p.stop != nil {
+		p.stop()
+	}
+	if p.span != nil {
+		p.span.End(result.Err)
+	}
+	if p.done != nil {
+		p.done(result, rejected)
+	} else {
+		p.ch <- result
+		close(p.ch)
 	}
+	delete(r.m, result.JobID)
 }
@@ -1,15 +1,19 @@
 package embat
 
 import (
+	"context"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // Test_results_add tests the add method of the results type.
 func Test_results_add(t *testing.T) {
 	// Initialise results type.
 	var r results[int]
-	r.m = make(map[JobID]chan Result[int])
+	r.m = make(map[JobID]*pending[int])
 	const numJobs = 5
 
 	// Add channels concurrently.
@@ -36,7 +40,7 @@ func Test_results_add(t *testing.T) {
 func Test_results_sendResults(t *testing.T) {
 	// Initialise results type.
 	var r results[int]
-	r.m = make(map[JobID]chan Result[int])
+	r.m = make(map[JobID]*pending[int])
 	const numJobs = 3
 
 	// Add channels to results.
@@ -54,7 +58,14 @@ func Test_results_sendResults(t *testing.T) {
 	}
 
 	// Send results to channels.
-	r.sendResults(jobResults)
+	var jobIDs []JobID
+	for _, result := range jobResults {
+		jobIDs = append(jobIDs, result.JobID)
+	}
+	o := r.sendResults(jobIDs, jobResults, nil)
+	if len(o.missing) != 0 || len(o.unmatched) != 0 {
+		t.Errorf("Expected all results to match, found %d missing and %d unmatched", len(o.missing), len(o.unmatched))
+	}
 
 	// Check if all channels are closed and removed from results.
 	r.mu.Lock()
@@ -63,3 +74,55 @@ func Test_results_sendResults(t *testing.T) {
 		t.Errorf("Expected all channels to be removed, found %d channels remaining", len(r.m))
 	}
 }
+
+// Test_results_sendResults_reconcile tests that results are reconciled against the jobs of the batch.
+func Test_results_sendResults_reconcile(t *testing.T) {
+	var r results[int]
+	r.m = make(map[JobID]*pending[int])
+	jobIDs := []JobID{"a", "b", "c"}
+	chs := make(map[JobID]chan Result[int])
+	for _, id := range jobIDs {
+		chs[id] = make(chan Result[int], 1)
+		r.add(id, chs[id])
+	}
+
+	o := r.sendResults(jobIDs, []Result[int]{
+		{JobID: "a", Result: 1},
+		{JobID: "a", Result: 2},
+		{JobID: "x", Result: 3},
+		{JobID: "c", Result: 4},
+	}, nil)
+
+	assert.Equal(t, []JobID{"b"}, o.missing)
+	assert.Equal(t, []unmatched[int]{
+		{result: Result[int]{JobID: "a", Result: 2}, err: ErrDuplicateResult},
+		{result: Result[int]{JobID: "x", Result: 3}, err: ErrUnknownJob},
+	}, o.unmatched)
+	assert.Equal(t, 1, (<-chs["a"]).Result)
+	assert.ErrorIs(t, (<-chs["b"]).Err, ErrNoResult)
+	assert.Equal(t, 4, (<-chs["c"]).Result)
+	assert.Empty(t, r.m)
+}
+
+// Test_results_reject tests that a rejected job receives the error without being observed,
+// and that a job withdrawn before it is rejected is not rejected again.
+func Test_results_reject(t *testing.T) {
+	observed := 0
+	r := results[int]{
+		m:       make(map[JobID]*pending[int]),
+		observe: func(time.Duration, error) { observed++ },
+	}
+	rejected := make(chan Result[int], 1)
+	r.add("rejected", rejected)
+	withdrawn := make(chan Result[int], 1)
+	r.add("withdrawn", withdrawn)
+
+	assert.True(t, r.reject("rejected", ErrQueueFull))
+	assert.ErrorIs(t, (<-rejected).Err, ErrQueueFull)
+	assert.Equal(t, 0, observed)
+
+	assert.True(t, r.abandon("withdrawn", context.Canceled))
+	assert.False(t, r.reject("withdrawn", context.Canceled))
+	assert.ErrorIs(t, (<-withdrawn).Err, context.Canceled)
+	assert.Equal(t, 1, observed)
+}
@@ -0,0 +1,47 @@
+package embat
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy decides whether and when a job whose result holds an error is processed again.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a job is processed, including the first attempt.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay before a retry, zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each retry, values below 1 default to 2.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, by which each delay is randomly lengthened or shortened.
+	Jitter float64
+	// Retryable reports whether a job that failed with err should be retried, if nil every error is retried.
+	Retryable func(err error) bool
+}
+
+// shouldRetry returns true if a job that failed with err after the given number of attempts should be retried.
+func (p *RetryPolicy) shouldRetry(attempts int, err error) bool {
+	if err == nil || attempts >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff returns the delay before retrying a job that has been attempted the given number of times.
+func (p *RetryPolicy) backoff(attempts int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempts-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,65 @@
+package embat
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRetryPolicy_shouldRetry tests which failures are retried.
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+	p := &RetryPolicy{
+		MaxAttempts: 3,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errTransient)
+		},
+	}
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		want     bool
+	}{
+		{name: "success", attempts: 1, err: nil, want: false},
+		{name: "retryable error", attempts: 1, err: errTransient, want: true},
+		{name: "permanent error", attempts: 1, err: errPermanent, want: false},
+		{name: "attempts exhausted", attempts: 3, err: errTransient, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.shouldRetry(tt.attempts, tt.err))
+		})
+	}
+}
+
+// TestRetryPolicy_backoff tests that the backoff grows exponentially up to the cap.
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+	assert.Equal(t, 10*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, p.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, p.backoff(4))
+
+	p.Multiplier = 3
+	assert.Equal(t, 30*time.Millisecond, p.backoff(2))
+}
+
+// TestRetryPolicy_backoff_jitter tests that the jitter keeps the backoff within bounds.
+func TestRetryPolicy_backoff_jitter(t *testing.T) {
+	p := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		Jitter:         0.5,
+	}
+	for i := 0; i < 100; i++ {
+		backoff := p.backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
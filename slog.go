@@ -0,0 +1,16 @@
+package embat
+
+import (
+	"log/slog"
+)
+
+var _ StructuredLogger = (*slog.Logger)(nil)
+
+// NewSlogLogger returns a StructuredLogger writing to the given slog handler, or to the default handler if it is nil.
+// A *slog.Logger can also be passed to WithStructuredLogger directly.
+func NewSlogLogger(handler slog.Handler) StructuredLogger {
+	if handler == nil {
+		return slog.Default()
+	}
+	return slog.New(handler)
+}
@@ -0,0 +1,76 @@
+package embat
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the runtime state of a MicroBatcher.
+type Stats struct {
+	// QueuedJobs is the number of jobs waiting in the queue to be dispatched.
+	QueuedJobs int
+	// InFlightBatches is the number of batches being processed.
+	InFlightBatches int
+	// PendingResults is the number of submitted jobs still awaiting their result.
+	PendingResults int
+	// Processed is the number of accepted jobs that received their result, including failed ones.
+	Processed uint64
+	// Failed is the number of processed jobs whose result held an error, e.g. because they were withdrawn.
+	Failed uint64
+	// Rejected is the number of jobs that were not accepted.
+	Rejected uint64
+	// LastBatch is when the most recent batch was dispatched, zero if none has been.
+	LastBatch time.Time
+	// ShuttingDown is set once shutdown has been called.
+	ShuttingDown bool
+	// Stopped is set once every job has been processed after shutdown.
+	Stopped bool
+}
+
+// counters accumulates the totals reported by Stats.
+type counters struct {
+	inFlight  atomic.Int64
+	processed atomic.Uint64
+	failed    atomic.Uint64
+	rejected  atomic.Uint64
+	// lastBatch is when the most recent batch was dispatched in Unix nanoseconds, zero if none has been.
+	lastBatch atomic.Int64
+}
+
+// Stats returns a snapshot of the runtime state of the MicroBatcher, it is safe to call concurrently.
+// The values are read independently of each other so they may be momentarily inconsistent.
+func (mb *MicroBatcher[J, R]) Stats() Stats {
+	stats := Stats{
+		QueuedJobs:      mb.jobs.Len(),
+		InFlightBatches: int(mb.counters.inFlight.Load()),
+		PendingResults:  mb.results.len(),
+		Processed:       mb.counters.processed.Load(),
+		Failed:          mb.counters.failed.Load(),
+		Rejected:        mb.counters.rejected.Load(),
+		ShuttingDown:    mb.isShutdown(),
+	}
+	if last := mb.counters.lastBatch.Load(); last != 0 {
+		stats.LastBatch = time.Unix(0, last)
+	}
+	select {
+	case <-mb.done:
+		stats.Stopped = true
+	default:
+	}
+	return stats
+}
+
+// rejected counts a job that was not accepted.
+func (mb *MicroBatcher[J, R]) rejected(err error) {
+	mb.counters.rejected.Add(1)
+	mb.metrics.JobRejected(err)
+}
+
+// completed counts a job that received its result.
+func (mb *MicroBatcher[J, R]) completed(latency time.Duration, err error) {
+	mb.counters.processed.Add(1)
+	if err != nil {
+		mb.counters.failed.Add(1)
+	}
+	mb.metrics.JobCompleted(latency, err)
+}
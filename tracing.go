@@ -0,0 +1,253 @@
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+package embat
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Names of the events the MicroBatcher records on job spans.
+const (
+	// EventEnqueued is recorded once the job has been queued.
+	EventEnqueued = "enqueued"
+	// EventCoalesced is recorded when the job joins a pending job with the same deduplication key.
+	EventCoalesced = "coalesced"
+	// EventDispatched is recorded every time the job is dispatched to the processor in a batch.
+	EventDispatched = "dispatched"
+	// EventRetrying is recorded when a failed job is scheduled for another attempt.
+	EventRetrying = "retrying"
+	// EventCompleted is recorded when the job receives its result, right before its span ends.
+	EventCompleted = "completed"
+)
+
+// Tracer traces jobs through the MicroBatcher, this interface can be implemented by the consumer,
+// e.g. on top of OpenTelemetry. Its methods are called from submitting and processing goroutines,
+// so implementations must be safe for concurrent use.
+type Tracer interface {
+	// StartJob starts the span of a submitted job, ctx is the submitting caller's context and carries its span.
+	StartJob(ctx context.Context, jobID JobID) Span
+	// StartBatch starts the span of a batch linked to the spans of its jobs, and returns the context
+	// carrying the batch span that the batch is processed with.
+	StartBatch(ctx context.Context, batchID string, links []Span) (context.Context, Span)
+}
+
+// Span is a traced operation started by a Tracer.
+type Span interface {
+	// AddEvent records an event on the span with alternating key-value attributes.
+	AddEvent(name string, attrs ...any)
+	// End ends the span, err is the error the operation failed with, if any.
+	End(err error)
+}
+
+// noOpTracer is a Tracer that does nothing.
+type noOpTracer struct{}
+
+// StartJob returns a span that does nothing.
+func (noOpTracer) StartJob(context.Context, JobID) Span {
+	return noOpSpan{}
+}
+
+// StartBatch returns ctx and a span that does nothing.
+func (noOpTracer) StartBatch(ctx context.Context, _ string, _ []Span) (context.Context, Span) {
+	return ctx, noOpSpan{}
+}
+
+// noOpSpan is a Span that does nothing.
+type noOpSpan struct{}
+
+// AddEvent does nothing.
+func (noOpSpan) AddEvent(string, ...any) {}
+
+// End does nothing.
+func (noOpSpan) End(error) {}
+
+// SpanID identifies a span recorded by a MemoryTracer, zero means no span.
+type SpanID uint64
+
+// SpanEvent is an event recorded on a span.
+type SpanEvent struct {
+	// Name is the name of the event.
+	Name string
+	// Attrs holds the alternating key-value attributes of the event.
+	Attrs []any
+	// Time is when the event was recorded.
+	Time time.Time
+}
+
+// RecordedSpan is a span recorded by a MemoryTracer.
+type RecordedSpan struct {
+	// ID identifies the span.
+	ID SpanID
+	// Parent is the span in the context the span was started with, if any.
+	Parent SpanID
+	// Name is "job" for job spans and "batch" for batch spans.
+	Name string
+	// JobID is the ID of the job of a job span.
+	JobID JobID
+	// BatchID is the ID of the batch of a batch span.
+	BatchID string
+	// Links holds the spans a batch span is linked to.
+	Links []SpanID
+	// Events holds the events recorded on the span.
+	Events []SpanEvent
+	// Ended is set once the span has ended.
+	Ended bool
+	// Err is the error the span ended with.
+	Err error
+}
+
+// spanKey is the context key of the span a MemoryTracer stores in a context.
+type spanKey struct{}
+
+// MemoryTracer is a Tracer that records spans in memory without any dependencies, e.g. to assert on in tests.
+type MemoryTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// NewMemoryTracer creates a MemoryTracer.
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+// Start starts a span, e.g. of the request submitting jobs, as a child of the span in ctx
+// and returns a context carrying it.
+func (t *MemoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := t.start(ctx, &RecordedSpan{Name: name})
+	return ContextWithSpan(ctx, s.id), s
+}
+
+// StartJob starts a job span as a child of the span in ctx.
+func (t *MemoryTracer) StartJob(ctx context.Context, jobID JobID) Span {
+	return t.start(ctx, &RecordedSpan{Name: "job", JobID: jobID})
+}
+
+// StartBatch starts a batch span linked to the given job spans and returns a context carrying it.
+func (t *MemoryTracer) StartBatch(ctx context.Context, batchID string, links []Span) (context.Context, Span) {
+	r := &RecordedSpan{Name: "batch", BatchID: batchID}
+	for _, link := range links {
+		if s, ok := link.(*memorySpan); ok {
+			r.Links = append(r.Links, s.id)
+		}
+	}
+	s := t.start(ctx, r)
+	return ContextWithSpan(ctx, s.id), s
+}
+
+// start records a new span as a child of the span in ctx.
+func (t *MemoryTracer) start(ctx context.Context, r *RecordedSpan) *memorySpan {
+	r.Parent = SpanFromContext(ctx)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r.ID = SpanID(len(t.spans) + 1)
+	t.spans = append(t.spans, r)
+	return &memorySpan{t: t, id: r.ID}
+}
+
+// Spans returns a copy of the spans recorded so far, in the order they were started.
+func (t *MemoryTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]RecordedSpan, len(t.spans))
+	for i, s := range t.spans {
+		spans[i] = *s
+		spans[i].Links = append([]SpanID(nil), s.Links...)
+		spans[i].Events = append([]SpanEvent(nil), s.Events...)
+	}
+	return spans
+}
+
+// ContextWithSpan returns a context carrying the span recorded by a MemoryTracer with the given ID.
+func ContextWithSpan(ctx context.Context, id SpanID) context.Context {
+	return context.WithValue(ctx, spanKey{}, id)
+}
+
+// SpanFromContext returns the ID of the span recorded by a MemoryTracer carried by ctx, zero if there is none.
+func SpanFromContext(ctx context.Context) SpanID {
+	id, _ := ctx.Value(spanKey{}).(SpanID)
+	return id
+}
+
+// memorySpan is a span recorded by a MemoryTracer.
+type memorySpan struct {
+	t  *MemoryTracer
+	id SpanID
+}
+
+// AddEvent records an event on the span.
+func (s *memorySpan) AddEvent(name string, attrs ...any) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	r := s.t.spans[s.id-1]
+	r.Events = append(r.Events, SpanEvent{Name: name, Attrs: attrs, Time: time.Now()})
+}
+
+// End ends the span.
+func (s *memorySpan) End(err error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	r := s.t.spans[s.id-1]
+	r.Ended = true
+	r.Err = err
+}
+
+// heldSpan wraps the span of a job being queued and holds back its events until the job has been queued or rejected,
+// so the enqueued event can be recorded before the job is visible to the start loop and withdrawn if it is rejected.
+type heldSpan struct {
+	span Span
+
+	mu   sync.Mutex
+	held []func(Span)
+	// released is set once the job has been queued or rejected, calls then go straight to span.
+	released bool
+}
+
+// holdSpan returns span wrapped to hold back its events until release is called.
+func holdSpan(span Span) *heldSpan {
+	return &heldSpan{span: span}
+}
+
+// AddEvent records an event on the span, or holds it back until the span is released.
+func (s *heldSpan) AddEvent(name string, attrs ...any) {
+	s.call(func(span Span) { span.AddEvent(name, attrs...) })
+}
+
+// End ends the span, or holds it back until the span is released.
+func (s *heldSpan) End(err error) {
+	s.call(func(span Span) { span.End(err) })
+}
+
+// call calls f with the span unless it is held.
+func (s *heldSpan) call(f func(Span)) {
+	s.mu.Lock()
+	if !s.released {
+		s.held = append(s.held, f)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	f(s.span)
+}
+
+// release passes the held back calls on to the span in order, skipping the first one if keepFirst is false.
+func (s *heldSpan) release(keepFirst bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := s.held
+	if !keepFirst && len(held) > 0 {
+		held = held[1:]
+	}
+	for _, f := range held {
+		f(s.span)
+	}
+	s.held, s.released = nil, true
+}
+
+// unwrapSpan returns the span started by the tracer, which span may wrap.
+func unwrapSpan(span Span) Span {
+	if s, ok := span.(*heldSpan); ok {
+		return s.span
+	}
+	return span
+}
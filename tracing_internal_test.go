@@ -0,0 +1,50 @@
+package embat
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_heldSpan tests that held back events are passed on in order once released,
+// without the first one if the job was rejected.
+func Test_heldSpan(t *testing.T) {
+	errRejected := errors.New("rejected")
+	tests := []struct {
+		name      string
+		keepFirst bool
+		events    []string
+		err       error
+	}{
+		{name: "queued", keepFirst: true, events: []string{EventEnqueued, EventDispatched, EventCompleted}},
+		{name: "rejected", keepFirst: false, events: []string{EventCompleted}, err: errRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewMemoryTracer()
+			job := tr.StartJob(context.Background(), "job-1")
+			s := holdSpan(job)
+			assert.Same(t, job, unwrapSpan(s))
+			s.AddEvent(EventEnqueued)
+			if tt.keepFirst {
+				s.AddEvent(EventDispatched)
+			}
+			assert.Empty(t, tr.Spans()[0].Events)
+
+			s.release(tt.keepFirst)
+			s.AddEvent(EventCompleted)
+			s.End(tt.err)
+
+			span := tr.Spans()[0]
+			var events []string
+			for _, e := range span.Events {
+				events = append(events, e.Name)
+			}
+			assert.Equal(t, tt.events, events)
+			assert.True(t, span.Ended)
+			assert.Equal(t, tt.err, span.Err)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package embat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nayanbhana/embat"
+)
+
+// TestMemoryTracer tests that spans are recorded with their parents, links and events.
+func TestMemoryTracer(t *testing.T) {
+	tr := embat.NewMemoryTracer()
+	ctx, request := tr.Start(context.Background(), "request")
+	job := tr.StartJob(ctx, "job-1")
+	job.AddEvent(embat.EventEnqueued)
+	batchCtx, batch := tr.StartBatch(context.Background(), "batch-1", []embat.Span{job})
+	errFailed := errors.New("failed")
+	batch.End(errFailed)
+	job.End(nil)
+	request.End(nil)
+
+	spans := tr.Spans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, embat.SpanID(1), embat.SpanFromContext(ctx))
+	assert.Equal(t, embat.SpanID(3), embat.SpanFromContext(batchCtx))
+	assert.Equal(t, embat.RecordedSpan{ID: 1, Name: "request", Ended: true}, spans[0])
+
+	assert.Equal(t, embat.SpanID(1), spans[1].Parent)
+	assert.Equal(t, embat.JobID("job-1"), spans[1].JobID)
+	require.Len(t, spans[1].Events, 1)
+	assert.Equal(t, embat.EventEnqueued, spans[1].Events[0].Name)
+	assert.True(t, spans[1].Ended)
+
+	assert.Equal(t, embat.SpanID(0), spans[2].Parent)
+	assert.Equal(t, "batch-1", spans[2].BatchID)
+	assert.Equal(t, []embat.SpanID{2}, spans[2].Links)
+	assert.ErrorIs(t, spans[2].Err, errFailed)
+}
@@ -0,0 +1,72 @@
+package embat
+
+// OversizePolicy decides what happens to a submitted job that weighs more than the maximum batch weight.
+type OversizePolicy int
+
+const (
+	// OversizeReject rejects the job straight away with ErrOversize, this is the default.
+	OversizeReject OversizePolicy = iota
+	// OversizeSendAlone accepts the job and processes it in a batch of its own.
+	OversizeSendAlone
+)
+
+// String returns the name of the policy.
+func (p OversizePolicy) String() string {
+	switch p {
+	case OversizeReject:
+		return "reject"
+	case OversizeSendAlone:
+		return "send-alone"
+	default:
+		return "unknown"
+	}
+}
+
+// weigh returns the weight of the job, zero if no weigher is configured.
+func (mb *MicroBatcher[J, R]) weigh(job Job[J]) int {
+	if mb.weigher == nil {
+		return 0
+	}
+	return mb.weigher(job)
+}
+
+// next takes up to size jobs of the given partition off the queue, closing the batch early if the next job
+// would exceed the maximum weight. A job that does not fit is held back as the first job of the partition's
+// following batch, so a job heavier than the maximum weight is always sent alone.
+func (mb *MicroBatcher[J, R]) next(key string, size int) []Job[J] {
+	take := mb.jobs.Next
+	if mb.partitions != nil {
+		take = func(n int) []Job[J] {
+			return mb.partitions.nextOf(key, n)
+		}
+	}
+	if mb.weigher == nil {
+		return take(size)
+	}
+	var batch []Job[J]
+	weight := 0
+	if job, ok := mb.carry[key]; ok {
+		batch = append(batch, job)
+		weight = mb.weigh(job)
+		delete(mb.carry, key)
+	}
+	for len(batch) < size {
+		jobs := take(1)
+		if len(jobs) == 0 {
+			break
+		}
+		job := jobs[0]
+		w := mb.weigh(job)
+		if len(batch) > 0 && weight+w > mb.maxWeight {
+			if mb.carry == nil {
+				mb.carry = make(map[string]Job[J])
+			}
+			mb.carry[key] = job
+			break
+		}
+		batch = append(batch, job)
+		weight += w
+	}
+	mb.queuedWeight.Add(-int64(weight))
+	return batch
+}
@@ -0,0 +1,45 @@
+package embat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_next_weight tests that batches close before exceeding the maximum weight and oversize jobs are sent alone.
+func Test_next_weight(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		sizes   []int
+	}{
+		{name: "within weight", weights: []int{1, 2, 2}, sizes: []int{3}},
+		{name: "closes on weight", weights: []int{2, 2, 2, 2}, sizes: []int{2, 2}},
+		{name: "oversize job sent alone", weights: []int{2, 2, 6, 1}, sizes: []int{2, 1, 1}},
+		{name: "closes on count", weights: []int{0, 0, 0, 0, 0}, sizes: []int{4, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &countingProcessor{}
+			mb := newTestBatcher(p, 4)
+			mb.maxBatchesPerTick = 0
+			mb.weigher = func(job Job[int]) int { return job.Data }
+			mb.maxWeight = 5
+			mb.oversizePolicy = OversizeSendAlone
+			for _, w := range tt.weights {
+				mb.Submit(NewJob(w))
+			}
+			mb.processTick()
+			mb.workers.Wait()
+			assert.Equal(t, tt.sizes, p.sizes)
+			assert.Zero(t, mb.queuedWeight.Load())
+		})
+	}
+}
+
+// TestOversizePolicy_String tests the names of the oversize policies.
+func TestOversizePolicy_String(t *testing.T) {
+	assert.Equal(t, "reject", OversizeReject.String())
+	assert.Equal(t, "send-alone", OversizeSendAlone.String())
+	assert.Equal(t, "unknown", OversizePolicy(42).String())
+}